@@ -39,8 +39,9 @@ func GetUserFromEmail(email string) (model.User, error) {
 	return user, nil
 }
 
+// Updates the profile of the user. The email and the password are updated by their own functions.
 func UpdateUser(user *model.User) error {
-	err := DB.Save(user).Error
+	err := DB.Model(user).Update("name", user.Name).Error
 
 	if err != nil {
 		fmt.Println("User could not update: ", err)
@@ -49,3 +50,25 @@ func UpdateUser(user *model.User) error {
 
 	return nil
 }
+
+func UpdateUserEmail(user *model.User, email string) error {
+	err := DB.Model(user).Update("email", email).Error
+
+	if err != nil {
+		fmt.Println("Email could not update: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func UpdateUserPassword(user *model.User, hash string) error {
+	err := DB.Model(user).Update("password", hash).Error
+
+	if err != nil {
+		fmt.Println("Password could not update: ", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package crud
+
+import (
+	"sort"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+	"gorm.io/gorm"
+)
+
+// Adds how much the sub transactions move the balance of each account title.
+// sign is 1 to post the sub transactions and -1 to revert them.
+func addBalanceDeltas(tx *gorm.DB, bookId string, subTransactions []model.SubTransaction, sign int64, deltas map[uint64]int64) error {
+	if len(subTransactions) == 0 {
+		return nil
+	}
+
+	accountTitleIds := make([]uint64, 0, len(subTransactions))
+	for _, subTransaction := range subTransactions {
+		accountTitleIds = append(accountTitleIds, subTransaction.AccountTitleId)
+	}
+
+	var accountTitles []model.AccountTitle
+	err := tx.Where(&model.AccountTitle{BookId: bookId}).Where("account_title_id IN ?", accountTitleIds).Find(&accountTitles).Error
+	if err != nil {
+		return err
+	}
+
+	accountTitleOf := make(map[uint64]*model.AccountTitle, len(accountTitles))
+	for idx := range accountTitles {
+		accountTitleOf[accountTitles[idx].AccountTitleId] = &accountTitles[idx]
+	}
+
+	return addBalanceDeltasOf(accountTitleOf, subTransactions, sign, deltas)
+}
+
+func addBalanceDeltasOf(accountTitleOf map[uint64]*model.AccountTitle, subTransactions []model.SubTransaction, sign int64, deltas map[uint64]int64) error {
+	for _, subTransaction := range subTransactions {
+		accountTitle, ok := accountTitleOf[subTransaction.AccountTitleId]
+		if !ok {
+			return gorm.ErrRecordNotFound
+		}
+		deltas[subTransaction.AccountTitleId] += sign * signedAmount(accountTitle, subTransaction.IsDebit, subTransaction.Amount)
+	}
+
+	return nil
+}
+
+// Updates the balances with amount = amount + delta so that concurrent postings are not lost.
+// Rows are updated in the order of the id to avoid deadlocks between transactions.
+func applyBalanceDeltas(tx *gorm.DB, bookId string, deltas map[uint64]int64) error {
+	accountTitleIds := make([]uint64, 0, len(deltas))
+	for accountTitleId, delta := range deltas {
+		if delta != 0 {
+			accountTitleIds = append(accountTitleIds, accountTitleId)
+		}
+	}
+	sort.Slice(accountTitleIds, func(i, j int) bool { return accountTitleIds[i] < accountTitleIds[j] })
+
+	for _, accountTitleId := range accountTitleIds {
+		result := tx.Model(&model.AccountTitle{}).
+			Where("account_title_id = ? AND book_id = ?", accountTitleId, bookId).
+			Update("amount", gorm.Expr("amount + ?", deltas[accountTitleId]))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+	}
+
+	return nil
+}
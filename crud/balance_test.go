@@ -0,0 +1,119 @@
+package crud
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+)
+
+// Postings and renames of the same account title at the same time must not lose any posting
+func TestConcurrentPostingsAndAccountTitleUpdates(t *testing.T) {
+	setupTestDB(t)
+	book, accountTitles := createTestBook(t, model.AccountCategoryAsset, model.AccountCategoryRevenue)
+	cash, sales := accountTitles[0], accountTitles[1]
+
+	const postings = 50
+	const deltas = 50
+	const renames = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, postings+deltas+renames)
+
+	for i := 0; i < postings; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- CreateTransaction(&model.Transaction{
+				BookId:      book.BookId,
+				Description: "sales",
+				OccurredAt:  time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local),
+				SubTransactions: []model.SubTransaction{
+					{BookId: book.BookId, IsDebit: true, AccountTitleId: cash.AccountTitleId, Amount: 100},
+					{BookId: book.BookId, IsDebit: false, AccountTitleId: sales.AccountTitleId, Amount: 100},
+				},
+			})
+		}()
+	}
+
+	for i := 0; i < deltas; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx := DB.Begin()
+			err := applyBalanceDeltas(tx, book.BookId, map[uint64]int64{cash.AccountTitleId: 10})
+			if err != nil {
+				tx.Rollback()
+				errs <- err
+				return
+			}
+			errs <- tx.Commit().Error
+		}()
+	}
+
+	for i := 0; i < renames; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// A copy read before the postings, as the endpoint does
+			title := cash
+			title.Name = fmt.Sprintf("cash %d", i)
+			errs <- UpdateAccountTitle(&title, nil)
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, want := amountOf(t, &cash), int64(postings*100+deltas*10); got != want {
+		t.Errorf("cash amount = %d, want %d", got, want)
+	}
+	if got, want := amountOf(t, &sales), int64(postings*100); got != want {
+		t.Errorf("sales amount = %d, want %d", got, want)
+	}
+}
+
+// The balance follows the opening balance after the postings
+func TestUpdateAccountTitleAmountBase(t *testing.T) {
+	setupTestDB(t)
+	book, accountTitles := createTestBook(t, model.AccountCategoryAsset, model.AccountCategoryRevenue)
+	cash, sales := accountTitles[0], accountTitles[1]
+
+	err := CreateTransaction(&model.Transaction{
+		BookId:      book.BookId,
+		Description: "sales",
+		OccurredAt:  time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local),
+		SubTransactions: []model.SubTransaction{
+			{BookId: book.BookId, IsDebit: true, AccountTitleId: cash.AccountTitleId, Amount: 300},
+			{BookId: book.BookId, IsDebit: false, AccountTitleId: sales.AccountTitleId, Amount: 300},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The copy has the balance before the posting
+	title := cash
+	title.AmountBase = 1000
+	if err := UpdateAccountTitle(&title, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := amountOf(t, &cash), int64(1300); got != want {
+		t.Errorf("cash amount = %d, want %d", got, want)
+	}
+
+	amount := int64(500)
+	if err := UpdateAccountTitle(&title, &amount); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := amountOf(t, &cash), int64(500); got != want {
+		t.Errorf("cash amount = %d, want %d", got, want)
+	}
+}
@@ -12,8 +12,28 @@ import (
 
 var DB *gorm.DB
 var NoAuthorizationError = errors.New("No Authorization")
+var InvalidAccountCategoryError = errors.New("Invalid Account Category")
+var InvalidParentAccountTitleError = errors.New("Invalid Parent Account Title")
+var SubAccountTitleCategoryError = errors.New("Sub Account Titles have another Category")
+var InvalidBookTemplateError = errors.New("Invalid Book Template")
+var NoRetainedEarningsTitleError = errors.New("No Retained Earnings Account Title")
+var AlreadyRolledOverError = errors.New("Book was already rolled over")
+var InvalidCursorError = errors.New("Invalid Cursor")
+var InvalidRuleError = errors.New("Invalid Rule")
+var InvalidRecurrenceRuleError = errors.New("Invalid Recurrence Rule")
 
 func InitDB() {
+	db, err := openDB()
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("db connected: ", &db)
+	DB = db
+}
+
+// Connects to the database of the environment variables and migrates it
+func openDB() (*gorm.DB, error) {
 	// Load Environment Variables
 	host := os.Getenv("POSTGRES_HOST")
 	port := os.Getenv("POSTGRES_PORT")
@@ -28,25 +48,30 @@ func InitDB() {
 		host, user, password, dbname, port, sslmode, timezone)
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp";`)
 
 	// Migration
-	db.AutoMigrate(
+	hasClosesAtYearEnd := db.Migrator().HasColumn(&model.AccountTitle{}, "closes_at_year_end")
+	err = db.AutoMigrate(
 		&model.User{}, &model.Application{}, &model.Permit{},
 
 		&model.Book{}, &model.AccountTitle{}, &model.BookAuthorization{},
 		&model.Transaction{}, &model.SubTransaction{},
+		&model.ImportProfile{}, &model.Rule{},
+		&model.RecurringTransaction{}, &model.RecurringSubTransaction{},
 	)
-
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	migrateAccountTitleType(db)
+	if !hasClosesAtYearEnd {
+		migrateClosesAtYearEnd(db)
 	}
 
-	fmt.Println("db connected: ", &db)
-	DB = db
+	return db, nil
 }
 
 func GetDB() *gorm.DB {
@@ -60,3 +85,83 @@ func CloseDB() {
 	}
 	db.Close()
 }
+
+// Categories of the numeric AccountTitle.Type of older versions.
+// Older versions documented only that even types were debit and odd types were credit,
+// so the meanings of 0..5 are inferred and should be checked against the real database.
+var legacyAccountTitleTypes = map[uint]struct {
+	category model.AccountCategory
+	isContra bool
+}{
+	0: {model.AccountCategoryAsset, false},     // 資産
+	1: {model.AccountCategoryLiability, false}, // 負債
+	2: {model.AccountCategoryExpense, false},   // 費用
+	3: {model.AccountCategoryRevenue, false},   // 収益
+	4: {model.AccountCategoryEquity, true},     // 事業主貸
+	5: {model.AccountCategoryEquity, false},    // 純資産
+}
+
+// Converts the numeric AccountTitle.Type of older versions into the category.
+// The type column is kept as legacy_type so that the migration can be verified.
+// Unknown types fall back to the parity rule of older versions (even is asset, odd is liability) and are logged.
+func migrateAccountTitleType(db *gorm.DB) {
+	if !db.Migrator().HasColumn(&model.AccountTitle{}, "type") {
+		return
+	}
+
+	var types []uint
+	err := db.Table("account_titles").Distinct("type").Pluck("type", &types).Error
+	if err != nil {
+		panic(err)
+	}
+
+	for _, accountType := range types {
+		legacyType, ok := legacyAccountTitleTypes[accountType]
+		if !ok {
+			legacyType.category = model.AccountCategoryAsset
+			if accountType%2 == 1 {
+				legacyType.category = model.AccountCategoryLiability
+			}
+
+			var accountTitleIds []uint64
+			err = db.Table("account_titles").Where("type = ? AND category = ''", accountType).Pluck("account_title_id", &accountTitleIds).Error
+			if err != nil {
+				panic(err)
+			}
+			fmt.Println("Unknown account title type ", accountType, " was migrated to ", legacyType.category, ", check the account titles: ", accountTitleIds)
+		}
+
+		err = db.Table("account_titles").Where("type = ? AND category = ''", accountType).Updates(map[string]interface{}{
+			"category":       legacyType.category,
+			"is_contra":      legacyType.isContra,
+			"normal_balance": model.NormalBalanceOf(legacyType.category, legacyType.isContra),
+		}).Error
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	err = db.Migrator().RenameColumn(&model.AccountTitle{}, "type", "legacy_type")
+	if err != nil {
+		panic(err)
+	}
+	err = db.Exec("ALTER TABLE account_titles ALTER COLUMN legacy_type DROP NOT NULL").Error
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("account title types migrated, the old types are kept in account_titles.legacy_type")
+}
+
+// Marks the equity accounts which older versions closed at year end (contra equity like 事業主貸)
+// and 事業主借 of the blue_return template, which was carried forward by mistake.
+func migrateClosesAtYearEnd(db *gorm.DB) {
+	result := db.Model(&model.AccountTitle{}).
+		Where("category = ? AND (is_contra OR code = ? OR name = ?)", model.AccountCategoryEquity, "2900", "事業主借").
+		Update("closes_at_year_end", true)
+	if result.Error != nil {
+		panic(result.Error)
+	}
+
+	fmt.Printf("%d account titles closed at year end\n", result.RowsAffected)
+}
@@ -0,0 +1,67 @@
+package crud
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+)
+
+var testDBOnce sync.Once
+var testDBErr error
+
+// Connects to the database of POSTGRES_* once, and skips the test if it is not set
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	if os.Getenv("POSTGRES_HOST") == "" {
+		t.Skip("POSTGRES_HOST is not set")
+	}
+
+	testDBOnce.Do(func() {
+		DB, testDBErr = openDB()
+	})
+	if testDBErr != nil {
+		t.Fatal("db could not connected: ", testDBErr)
+	}
+}
+
+// Book with the account titles of the categories, deleted after the test
+func createTestBook(t *testing.T, categories ...model.AccountCategory) (model.Book, []model.AccountTitle) {
+	t.Helper()
+
+	book := model.Book{Name: t.Name(), Year: 2024}
+	if err := DB.Create(&book).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		DB.Where(&model.Book{BookId: book.BookId}).Delete(&model.Book{})
+	})
+
+	accountTitles := make([]model.AccountTitle, 0, len(categories))
+	for _, category := range categories {
+		accountTitle := model.AccountTitle{
+			BookId:        book.BookId,
+			Name:          string(category),
+			Category:      category,
+			NormalBalance: model.NormalBalanceOf(category, false),
+		}
+		if err := CreateAccountTitle(&accountTitle); err != nil {
+			t.Fatal(err)
+		}
+		accountTitles = append(accountTitles, accountTitle)
+	}
+
+	return book, accountTitles
+}
+
+func amountOf(t *testing.T, accountTitle *model.AccountTitle) int64 {
+	t.Helper()
+
+	var stored model.AccountTitle
+	err := DB.Where(&model.AccountTitle{AccountTitleId: accountTitle.AccountTitleId, BookId: accountTitle.BookId}).First(&stored).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stored.Amount
+}
@@ -0,0 +1,166 @@
+package crud
+
+import (
+	"fmt"
+	"time"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+	"gorm.io/gorm"
+)
+
+const exportBatchSize = 500
+
+// Name of the counter account when there are several (諸口)
+const miscellaneousTitleName = "諸口"
+
+// Calls write with the journal rows of the posted transactions of the period [from, to) in batches, ordered by the date
+func ExportJournal(book *model.Book, from time.Time, to time.Time, write func(rows []model.JournalRow) error) error {
+	var cursor *transactionCursor
+	for {
+		var transactions []model.Transaction
+		q := DB.Preload("SubTransactions", func(db *gorm.DB) *gorm.DB { return db.Order("sub_transactions.sub_transaction_id") }).
+			Preload("SubTransactions.AccountTitle").
+			Where("book_id = ? AND occurred_at >= ? AND occurred_at < ?", book.BookId, from, to).
+			Where(&model.Transaction{Status: model.TransactionStatusPosted})
+		if cursor != nil {
+			q = q.Where("(occurred_at, transaction_id) > (?, ?)", cursor.OccurredAt, cursor.TransactionId)
+		}
+		err := q.Order("occurred_at, transaction_id").Limit(exportBatchSize).Find(&transactions).Error
+		if err != nil {
+			fmt.Println("Journal could not exported: ", err)
+			return err
+		}
+		if len(transactions) == 0 {
+			return nil
+		}
+
+		rows := []model.JournalRow{}
+		for idx := range transactions {
+			rows = append(rows, journalRowsOf(&transactions[idx])...)
+		}
+		if err := write(rows); err != nil {
+			return err
+		}
+
+		if len(transactions) < exportBatchSize {
+			return nil
+		}
+		last := transactions[len(transactions)-1]
+		cursor = &transactionCursor{OccurredAt: last.OccurredAt, TransactionId: last.TransactionId}
+	}
+}
+
+// Calls write with the entries of the ledger from GetLedgerOpening in batches, ordered by the date.
+// The totals and the closing balance of the ledger are added up batch by batch.
+func ExportLedgerEntries(book *model.Book, ledger *model.Ledger, write func(entries []model.LedgerEntry) error) error {
+	var cursor *subTransactionCursor
+	for {
+		var subTransactions []model.SubTransaction
+		q := DB.Preload("Transaction").
+			Joins("JOIN transactions ON transactions.transaction_id = sub_transactions.transaction_id AND transactions.book_id = sub_transactions.book_id").
+			Where("sub_transactions.book_id = ? AND sub_transactions.account_title_id = ?", book.BookId, ledger.AccountTitle.AccountTitleId).
+			Where("transactions.occurred_at >= ? AND transactions.occurred_at < ?", ledger.From, ledger.To).
+			Where("transactions.status = ?", model.TransactionStatusPosted)
+		if cursor != nil {
+			q = q.Where("(transactions.occurred_at, sub_transactions.transaction_id, sub_transactions.sub_transaction_id) > (?, ?, ?)", cursor.OccurredAt, cursor.TransactionId, cursor.SubTransactionId)
+		}
+		err := q.Order("transactions.occurred_at, sub_transactions.transaction_id, sub_transactions.sub_transaction_id").
+			Limit(exportBatchSize).Find(&subTransactions).Error
+		if err != nil {
+			fmt.Println("No Sub Transactions", err)
+			return err
+		}
+		if len(subTransactions) == 0 {
+			return nil
+		}
+
+		entries, err := ledgerEntriesOf(book, ledger, subTransactions)
+		if err != nil {
+			return err
+		}
+		if err := write(entries); err != nil {
+			return err
+		}
+
+		if len(subTransactions) < exportBatchSize {
+			return nil
+		}
+		last := subTransactions[len(subTransactions)-1]
+		cursor = &subTransactionCursor{OccurredAt: last.Transaction.OccurredAt, TransactionId: last.TransactionId, SubTransactionId: last.SubTransactionId}
+	}
+}
+
+// Puts the debit lines and the credit lines of the transaction side by side
+func journalRowsOf(transaction *model.Transaction) []model.JournalRow {
+	var debits, credits []model.SubTransaction
+	for _, subTransaction := range transaction.SubTransactions {
+		if subTransaction.IsDebit {
+			debits = append(debits, subTransaction)
+		} else {
+			credits = append(credits, subTransaction)
+		}
+	}
+
+	n := len(debits)
+	if len(credits) > n {
+		n = len(credits)
+	}
+
+	rows := make([]model.JournalRow, 0, n)
+	for idx := 0; idx < n; idx++ {
+		row := model.JournalRow{
+			OccurredAt:    transaction.OccurredAt,
+			TransactionId: transaction.TransactionId,
+			Description:   transaction.Description,
+		}
+		if idx < len(debits) {
+			row.DebitTitle = accountTitleNameOf(&debits[idx])
+			row.DebitAmount = debits[idx].Amount
+		}
+		if idx < len(credits) {
+			row.CreditTitle = accountTitleNameOf(&credits[idx])
+			row.CreditAmount = credits[idx].Amount
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func accountTitleNameOf(subTransaction *model.SubTransaction) string {
+	if subTransaction.AccountTitle == nil {
+		return ""
+	}
+	return subTransaction.AccountTitle.Name
+}
+
+// Rows of the ledger entries with the account title on its side and the counter account on the other
+func LedgerRowsOf(accountTitle *model.AccountTitle, entries []model.LedgerEntry) []model.JournalRow {
+	rows := make([]model.JournalRow, 0, len(entries))
+	for _, entry := range entries {
+		counterTitle := miscellaneousTitleName
+		if len(entry.CounterAccounts) == 1 {
+			counterTitle = entry.CounterAccounts[0].Name
+		}
+
+		row := model.JournalRow{
+			OccurredAt:    entry.OccurredAt,
+			TransactionId: entry.TransactionId,
+			Description:   entry.Description,
+		}
+		if entry.Debit > 0 {
+			row.DebitTitle = accountTitle.Name
+			row.DebitAmount = entry.Debit
+			row.CreditTitle = counterTitle
+			row.CreditAmount = entry.Debit
+		} else {
+			row.DebitTitle = counterTitle
+			row.DebitAmount = entry.Credit
+			row.CreditTitle = accountTitle.Name
+			row.CreditAmount = entry.Credit
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
@@ -0,0 +1,67 @@
+package crud
+
+import (
+	"strings"
+	"time"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+	"gorm.io/gorm"
+)
+
+// Conditions of transactions.
+// AccountTitleIds, MinAmount, MaxAmount and IsDebit must all match the same sub transaction.
+// To is exclusive.
+type TransactionFilter struct {
+	From            *time.Time
+	To              *time.Time
+	AccountTitleIds []uint64
+	MinAmount       *int64
+	MaxAmount       *int64
+	IsDebit         *bool
+	Description     string
+	Status          model.TransactionStatus
+}
+
+func (filter *TransactionFilter) hasSubTransactionConditions() bool {
+	return len(filter.AccountTitleIds) > 0 || filter.MinAmount != nil || filter.MaxAmount != nil || filter.IsDebit != nil
+}
+
+func filterTransactions(db *gorm.DB, filter *TransactionFilter) *gorm.DB {
+	if filter == nil {
+		return db
+	}
+
+	if filter.From != nil {
+		db = db.Where("transactions.occurred_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		db = db.Where("transactions.occurred_at < ?", *filter.To)
+	}
+	if filter.Status != "" {
+		db = db.Where("transactions.status = ?", filter.Status)
+	}
+	if filter.Description != "" {
+		escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+		db = db.Where("transactions.description ILIKE ?", "%"+escaper.Replace(filter.Description)+"%")
+	}
+
+	if filter.hasSubTransactionConditions() {
+		subQuery := DB.Model(&model.SubTransaction{}).Select("1").
+			Where("sub_transactions.book_id = transactions.book_id AND sub_transactions.transaction_id = transactions.transaction_id")
+		if len(filter.AccountTitleIds) > 0 {
+			subQuery = subQuery.Where("sub_transactions.account_title_id IN ?", filter.AccountTitleIds)
+		}
+		if filter.MinAmount != nil {
+			subQuery = subQuery.Where("sub_transactions.amount >= ?", *filter.MinAmount)
+		}
+		if filter.MaxAmount != nil {
+			subQuery = subQuery.Where("sub_transactions.amount <= ?", *filter.MaxAmount)
+		}
+		if filter.IsDebit != nil {
+			subQuery = subQuery.Where("sub_transactions.is_debit = ?", *filter.IsDebit)
+		}
+		db = db.Where("EXISTS (?)", subQuery)
+	}
+
+	return db
+}
@@ -0,0 +1,198 @@
+package crud
+
+import (
+	"fmt"
+	"strconv"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+	"gorm.io/gorm"
+)
+
+func CreateImportProfile(profile *model.ImportProfile) error {
+	err := DB.Create(profile).Error
+
+	if err != nil {
+		fmt.Println("Import Profile could not create: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func GetImportProfile(book *model.Book, importProfileId uint64) (model.ImportProfile, error) {
+	var profile model.ImportProfile
+	err := DB.Where(&model.ImportProfile{ImportProfileId: importProfileId, BookId: book.BookId}).First(&profile).Error
+
+	if err != nil {
+		fmt.Println("Import Profile could not found: ", err)
+		return model.ImportProfile{}, err
+	}
+
+	return profile, nil
+}
+
+func GetImportProfiles(book *model.Book) (*[]model.ImportProfile, error) {
+	var profiles []model.ImportProfile
+	err := DB.Where(&model.ImportProfile{BookId: book.BookId}).Order("import_profile_id").Find(&profiles).Error
+
+	if err != nil {
+		fmt.Println("No Import Profiles", err)
+		return nil, err
+	}
+
+	return &profiles, nil
+}
+
+func UpdateImportProfile(profile *model.ImportProfile) error {
+	err := DB.Save(profile).Error
+
+	if err != nil {
+		fmt.Println("Import Profile could not update: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func DeleteImportProfile(book *model.Book, importProfileId uint64) error {
+	result := DB.Where(&model.ImportProfile{ImportProfileId: importProfileId, BookId: book.BookId}).Delete(&model.ImportProfile{})
+
+	if result.Error != nil {
+		fmt.Println("Import Profile could not delete: ", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Creates a draft transaction between the account title of the statement (bank, credit card, ...) and the counter account for each line.
+// The counter account is decided by the rules of the book, or counterAccountTitleId if no rule matches.
+// Without both, the draft has only the line of the account title until the counter account is added.
+// Lines imported before are skipped by the external ID. All the other lines are imported or none of them.
+func ImportStatement(book *model.Book, accountTitleId uint64, counterAccountTitleId uint64, lines []model.StatementLine) ([]model.Transaction, int, error) {
+	transactions := make([]model.Transaction, 0, len(lines))
+
+	tx := DB.Begin()
+	imported, err := importedExternalIds(tx, book, accountTitleId, lines)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Statement Import Error: ", err)
+		return nil, 0, err
+	}
+
+	rules, err := getCompiledRules(tx, book.BookId)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Statement Import Error: ", err)
+		return nil, 0, err
+	}
+
+	skipped := 0
+	for _, line := range lines {
+		transaction := statementTransactionOf(book, accountTitleId, &line)
+		if transaction.ExternalId != "" && imported[transaction.ExternalId] {
+			skipped++
+			continue
+		}
+
+		if !completeTransactionWithRules(rules, &transaction) && counterAccountTitleId != 0 {
+			source := transaction.SubTransactions[0]
+			transaction.SubTransactions = append(transaction.SubTransactions, model.SubTransaction{
+				BookId:         book.BookId,
+				IsDebit:        !source.IsDebit,
+				AccountTitleId: counterAccountTitleId,
+				Amount:         source.Amount,
+			})
+		}
+
+		err := insertTransaction(tx, &transaction, rules, map[uint64]int64{})
+		if err != nil {
+			tx.Rollback()
+			fmt.Printf("Statement Import Error at row %d: %v\n", line.Row, err)
+			return nil, 0, &StatementImportError{Row: line.Row, Err: err}
+		}
+		transactions = append(transactions, transaction)
+		if transaction.ExternalId != "" {
+			imported[transaction.ExternalId] = true
+		}
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		fmt.Println("Statement Import Commit Error: ", err)
+		return nil, 0, err
+	}
+
+	return transactions, skipped, nil
+}
+
+// The external ID of the line is unique only in the statement of the account title
+func statementExternalIdOf(accountTitleId uint64, line *model.StatementLine) string {
+	if line.ExternalId == "" {
+		return ""
+	}
+	return strconv.FormatUint(accountTitleId, 10) + ":" + line.ExternalId
+}
+
+func importedExternalIds(tx *gorm.DB, book *model.Book, accountTitleId uint64, lines []model.StatementLine) (map[string]bool, error) {
+	externalIds := make([]string, 0, len(lines))
+	for idx := range lines {
+		if externalId := statementExternalIdOf(accountTitleId, &lines[idx]); externalId != "" {
+			externalIds = append(externalIds, externalId)
+		}
+	}
+
+	imported := map[string]bool{}
+	if len(externalIds) == 0 {
+		return imported, nil
+	}
+
+	var existingIds []string
+	err := tx.Model(&model.Transaction{}).Where("book_id = ? AND external_id IN ?", book.BookId, externalIds).Pluck("external_id", &existingIds).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, externalId := range existingIds {
+		imported[externalId] = true
+	}
+
+	return imported, nil
+}
+
+// Draft transaction with only the line of the account title.
+// A deposit is debited to the account title and a withdrawal is credited to it.
+func statementTransactionOf(book *model.Book, accountTitleId uint64, line *model.StatementLine) model.Transaction {
+	amount := line.Amount
+	isDeposit := amount > 0
+	if !isDeposit {
+		amount = -amount
+	}
+
+	return model.Transaction{
+		BookId:      book.BookId,
+		Description: line.Description,
+		OccurredAt:  line.OccurredAt,
+		ExternalId:  statementExternalIdOf(accountTitleId, line),
+		Status:      model.TransactionStatusDraft,
+		SubTransactions: []model.SubTransaction{
+			{BookId: book.BookId, IsDebit: isDeposit, AccountTitleId: accountTitleId, Amount: amount},
+		},
+	}
+}
+
+// Row is the row of the statement where the import failed
+type StatementImportError struct {
+	Row int
+	Err error
+}
+
+func (e *StatementImportError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *StatementImportError) Unwrap() error {
+	return e.Err
+}
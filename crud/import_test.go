@@ -0,0 +1,76 @@
+package crud
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+	util "github.com/Prokuma/PLAccounting-Backend/utils"
+)
+
+// Imported transactions are drafts, even when they are balanced, and do not change the balances
+func TestImportStatementCreatesDrafts(t *testing.T) {
+	setupTestDB(t)
+
+	book, accountTitles := createTestBook(t, testCategories...)
+	lines := []model.StatementLine{
+		{Row: 1, Description: "SALES", Amount: 1000, OccurredAt: time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local), ExternalId: "1"},
+		{Row: 2, Description: "SHOP", Amount: -300, OccurredAt: time.Date(2024, 4, 2, 0, 0, 0, 0, time.Local), ExternalId: "2"},
+	}
+
+	transactions, _, err := ImportStatement(&book, accountTitles[bank].AccountTitleId, accountTitles[sales].AccountTitleId, lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != len(lines) {
+		t.Fatalf("imported %d transactions, want %d", len(transactions), len(lines))
+	}
+	for _, transaction := range transactions {
+		if transaction.Status != model.TransactionStatusDraft {
+			t.Errorf("%s: status = %q, want draft", transaction.Description, transaction.Status)
+		}
+		if len(transaction.SubTransactions) != 2 {
+			t.Errorf("%s: %d lines, want 2", transaction.Description, len(transaction.SubTransactions))
+		}
+	}
+	for idx := range accountTitles {
+		if amount := amountOf(t, &accountTitles[idx]); amount != 0 {
+			t.Errorf("%s: amount = %d, want 0", accountTitles[idx].Name, amount)
+		}
+	}
+}
+
+// Lines of an overlapping statement which were imported before are skipped by the hash IDs
+func TestImportStatementSkipsImportedLines(t *testing.T) {
+	setupTestDB(t)
+
+	book, accountTitles := createTestBook(t, testCategories...)
+	profile := model.ImportProfile{
+		Encoding:          util.CSVEncodingUTF8,
+		DateColumn:        0,
+		DescriptionColumn: 1,
+		WithdrawalColumn:  -1,
+		DepositColumn:     -1,
+		AmountColumn:      2,
+	}
+	statements := []string{
+		"2024/3/30,コンビニ,-500\n2024/3/31,コンビニ,-500\n2024/3/31,コンビニ,-500\n",
+		"2024/3/31,コンビニ,-500\n2024/3/31,コンビニ,-500\n2024/4/1,給与,300000\n",
+	}
+	want := []struct{ imported, skipped int }{{3, 0}, {1, 2}}
+
+	for idx, statement := range statements {
+		lines, err := util.ParseStatementCSV(strings.NewReader(statement), &profile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		transactions, skipped, err := ImportStatement(&book, accountTitles[bank].AccountTitleId, 0, lines)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(transactions) != want[idx].imported || skipped != want[idx].skipped {
+			t.Errorf("statement %d: imported %d and skipped %d, want %d and %d", idx, len(transactions), skipped, want[idx].imported, want[idx].skipped)
+		}
+	}
+}
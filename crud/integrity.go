@@ -0,0 +1,94 @@
+package crud
+
+import (
+	"fmt"
+	"time"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+	"gorm.io/gorm/clause"
+)
+
+// Recomputes balances of the book from AmountBase and the sub transactions.
+// The stored balances are overwritten with the recomputed ones when repair is true.
+func CheckBalances(book *model.Book, repair bool) ([]model.BalanceDiscrepancy, error) {
+	tx := DB.Begin()
+	if repair {
+		// Postings to the book wait until the repair is committed
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(&model.AccountTitle{BookId: book.BookId}).Find(&[]model.AccountTitle{}).Error
+		if err != nil {
+			tx.Rollback()
+			fmt.Println("Account Titles could not locked: ", err)
+			return nil, err
+		}
+	}
+
+	balances, err := getAccountTitleBalances(tx, book.BookId, time.Time{}, time.Time{})
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Balances could not calculated: ", err)
+		return nil, err
+	}
+
+	discrepancies := []model.BalanceDiscrepancy{}
+	for _, balance := range balances {
+		accountTitle := balance.AccountTitle
+		if accountTitle.Amount == balance.Closing {
+			continue
+		}
+
+		discrepancies = append(discrepancies, model.BalanceDiscrepancy{
+			BookId:         book.BookId,
+			AccountTitleId: accountTitle.AccountTitleId,
+			Code:           accountTitle.Code,
+			Name:           accountTitle.Name,
+			Amount:         accountTitle.Amount,
+			Expected:       balance.Closing,
+			Difference:     accountTitle.Amount - balance.Closing,
+		})
+
+		if !repair {
+			continue
+		}
+		err = tx.Model(&model.AccountTitle{}).
+			Where(&model.AccountTitle{AccountTitleId: accountTitle.AccountTitleId, BookId: book.BookId}).
+			Update("amount", balance.Closing).Error
+		if err != nil {
+			tx.Rollback()
+			fmt.Println("Account Title Update Error: ", err)
+			return nil, err
+		}
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		fmt.Println("Balance Check Commit Error: ", err)
+		return nil, err
+	}
+
+	return discrepancies, nil
+}
+
+// Checks the books of the ids, or all books when no id is given
+func CheckAllBalances(bookIds []string, repair bool) ([]model.BalanceDiscrepancy, error) {
+	var books []model.Book
+	q := DB.Order("created_at")
+	if len(bookIds) > 0 {
+		q = q.Where("book_id IN ?", bookIds)
+	}
+	err := q.Find(&books).Error
+	if err != nil {
+		fmt.Println("Books could not found: ", err)
+		return nil, err
+	}
+
+	discrepancies := []model.BalanceDiscrepancy{}
+	for idx := range books {
+		bookDiscrepancies, err := CheckBalances(&books[idx], repair)
+		if err != nil {
+			return nil, err
+		}
+		discrepancies = append(discrepancies, bookDiscrepancies...)
+	}
+
+	return discrepancies, nil
+}
@@ -0,0 +1,85 @@
+package crud
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// Limit 0 means no limit.
+// Cursor is the next_cursor of the previous page. Page is used for offset paging when Cursor is empty.
+type Pagination struct {
+	Limit  int
+	Cursor string
+	Page   int
+}
+
+// NextCursor is empty on the last page.
+type PageInfo struct {
+	Total      int64  `json:"total"`
+	NextCursor string `json:"next_cursor"`
+}
+
+type transactionCursor struct {
+	OccurredAt    time.Time `json:"occurred_at"`
+	TransactionId uint64    `json:"transaction_id"`
+}
+
+type subTransactionCursor struct {
+	OccurredAt       time.Time `json:"occurred_at"`
+	TransactionId    uint64    `json:"transaction_id"`
+	SubTransactionId uint64    `json:"sub_transaction_id"`
+}
+
+type accountTitleCursor struct {
+	SortOrder      int    `json:"sort_order"`
+	Code           string `json:"code"`
+	AccountTitleId uint64 `json:"account_title_id"`
+}
+
+type bookCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	BookId    string    `json:"book_id"`
+}
+
+func encodeCursor(cursor interface{}) string {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(encoded string, cursor interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return InvalidCursorError
+	}
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return InvalidCursorError
+	}
+	return nil
+}
+
+// Fetches one more row than the limit to know whether the next page exists
+func (pagination *Pagination) fetchLimit() int {
+	if pagination.Limit <= 0 {
+		return -1
+	}
+	return pagination.Limit + 1
+}
+
+func (pagination *Pagination) offset() int {
+	if pagination.Cursor != "" || pagination.Limit <= 0 || pagination.Page <= 0 {
+		return -1
+	}
+	return pagination.Limit * pagination.Page
+}
+
+// Trims the extra row fetched by fetchLimit and reports whether there was one
+func (pagination *Pagination) hasNext(fetched int) (int, bool) {
+	if pagination.Limit > 0 && fetched > pagination.Limit {
+		return pagination.Limit, true
+	}
+	return fetched, false
+}
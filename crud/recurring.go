@@ -0,0 +1,314 @@
+package crud
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+	util "github.com/Prokuma/PLAccounting-Backend/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	recurringBatchSize = 100
+	// Occurrences created for a schedule in a run, the rest are created in the next runs
+	maxRecurringCatchUp = 100
+)
+
+func recurringExternalIdOf(recurring *model.RecurringTransaction, occurrence time.Time) string {
+	return fmt.Sprintf("recurring:%d:%s", recurring.RecurringTransactionId, occurrence.Format("2006-01-02"))
+}
+
+func recurringStatusOf(recurring *model.RecurringTransaction) model.TransactionStatus {
+	if recurring.AsDraft {
+		return model.TransactionStatusDraft
+	}
+	return model.TransactionStatusPosted
+}
+
+func recurringSubTransactionsOf(recurring *model.RecurringTransaction) []model.SubTransaction {
+	subTransactions := make([]model.SubTransaction, 0, len(recurring.SubTransactions))
+	for _, line := range recurring.SubTransactions {
+		subTransactions = append(subTransactions, model.SubTransaction{
+			BookId:         recurring.BookId,
+			IsDebit:        line.IsDebit,
+			AccountTitleId: line.AccountTitleId,
+			Amount:         line.Amount,
+		})
+	}
+	return subTransactions
+}
+
+// First occurrence after the last created one, or from StartsAt if nothing was created yet
+func nextOccurrenceOf(recurring *model.RecurringTransaction) (*time.Time, error) {
+	rule, err := util.ParseRRule(recurring.Rule)
+	if err != nil {
+		return nil, InvalidRecurrenceRuleError
+	}
+
+	// Occurrences are dates, so the day of StartsAt is the first candidate whatever time it is
+	startsAt := recurring.StartsAt.In(time.Local)
+	after := time.Date(startsAt.Year(), startsAt.Month(), startsAt.Day(), 0, 0, 0, 0, time.Local).Add(-time.Nanosecond)
+	if recurring.LastOccurrence != nil {
+		after = recurring.LastOccurrence.In(time.Local)
+	}
+
+	next, ok, err := rule.Next(startsAt, after)
+	if err != nil {
+		return nil, InvalidRecurrenceRuleError
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &next, nil
+}
+
+// Next n occurrences which are not created yet
+func UpcomingOccurrences(recurring *model.RecurringTransaction, n int) []time.Time {
+	occurrences := []time.Time{}
+	rule, err := util.ParseRRule(recurring.Rule)
+	if err != nil || recurring.NextOccurrence == nil {
+		return occurrences
+	}
+
+	startsAt := recurring.StartsAt.In(time.Local)
+	occurrence := recurring.NextOccurrence.In(time.Local)
+	for len(occurrences) < n {
+		occurrences = append(occurrences, occurrence)
+		next, ok, err := rule.Next(startsAt, occurrence)
+		if err != nil || !ok {
+			break
+		}
+		occurrence = next
+	}
+
+	return occurrences
+}
+
+// Checks the rule and the lines, and sets the next occurrence
+func prepareRecurringTransaction(db *gorm.DB, recurring *model.RecurringTransaction) error {
+	for idx := range recurring.SubTransactions {
+		recurring.SubTransactions[idx].BookId = recurring.BookId
+	}
+
+	next, err := nextOccurrenceOf(recurring)
+	if err != nil {
+		return err
+	}
+	recurring.NextOccurrence = next
+
+	return validateSubTransactions(db, recurring.BookId, recurringSubTransactionsOf(recurring), recurringStatusOf(recurring))
+}
+
+func CreateRecurringTransaction(recurring *model.RecurringTransaction) error {
+	err := prepareRecurringTransaction(DB, recurring)
+	if err != nil {
+		fmt.Println("Recurring Transaction Validation Error: ", err)
+		return err
+	}
+
+	err = DB.Create(recurring).Error
+	if err != nil {
+		fmt.Println("Recurring Transaction could not create: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func GetRecurringTransaction(book *model.Book, recurringTransactionId uint64) (model.RecurringTransaction, error) {
+	var recurring model.RecurringTransaction
+	err := DB.Preload("SubTransactions", func(db *gorm.DB) *gorm.DB { return db.Order("recurring_sub_transaction_id") }).
+		Where(&model.RecurringTransaction{RecurringTransactionId: recurringTransactionId, BookId: book.BookId}).First(&recurring).Error
+
+	if err != nil {
+		fmt.Println("Recurring Transaction could not found: ", err)
+		return model.RecurringTransaction{}, err
+	}
+
+	return recurring, nil
+}
+
+func GetRecurringTransactions(book *model.Book) (*[]model.RecurringTransaction, error) {
+	var recurrings []model.RecurringTransaction
+	err := DB.Preload("SubTransactions", func(db *gorm.DB) *gorm.DB { return db.Order("recurring_sub_transaction_id") }).
+		Where(&model.RecurringTransaction{BookId: book.BookId}).Order("recurring_transaction_id").Find(&recurrings).Error
+
+	if err != nil {
+		fmt.Println("No Recurring Transactions", err)
+		return nil, err
+	}
+
+	return &recurrings, nil
+}
+
+// Replaces the lines with recurring.SubTransactions.
+// The transactions already created are kept, and the next occurrence is after the last created one.
+func UpdateRecurringTransaction(recurring *model.RecurringTransaction) error {
+	tx := DB.Begin()
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("recurring_transaction_id").
+		Where(&model.RecurringTransaction{RecurringTransactionId: recurring.RecurringTransactionId, BookId: recurring.BookId}).
+		First(&model.RecurringTransaction{}).Error
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Recurring Transaction not found: ", err)
+		return err
+	}
+
+	err = prepareRecurringTransaction(tx, recurring)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Recurring Transaction Validation Error: ", err)
+		return err
+	}
+
+	err = tx.Omit("SubTransactions").Save(recurring).Error
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Recurring Transaction could not update: ", err)
+		return err
+	}
+
+	err = tx.Where(&model.RecurringSubTransaction{RecurringTransactionId: recurring.RecurringTransactionId, BookId: recurring.BookId}).
+		Delete(&model.RecurringSubTransaction{}).Error
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Recurring Sub Transactions could not delete: ", err)
+		return err
+	}
+
+	for idx := range recurring.SubTransactions {
+		line := &recurring.SubTransactions[idx]
+		line.RecurringSubTransactionId = 0
+		line.RecurringTransactionId = recurring.RecurringTransactionId
+	}
+	if len(recurring.SubTransactions) > 0 {
+		err = tx.Create(&recurring.SubTransactions).Error
+		if err != nil {
+			tx.Rollback()
+			fmt.Println("Recurring Sub Transactions could not create: ", err)
+			return err
+		}
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		fmt.Println("Transaction Commit Error: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// The transactions already created are kept
+func DeleteRecurringTransaction(book *model.Book, recurringTransactionId uint64) error {
+	result := DB.Where(&model.RecurringTransaction{RecurringTransactionId: recurringTransactionId, BookId: book.BookId}).Delete(&model.RecurringTransaction{})
+
+	if result.Error != nil {
+		fmt.Println("Recurring Transaction could not delete: ", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Creates the transactions of the schedules whose occurrences are due at now, and returns the number of them.
+// The schedules are locked with SKIP LOCKED so that the servers running at the same time do not create the same transactions,
+// and the transactions and the next occurrences are committed together, so each occurrence is created exactly once even across restarts.
+// The external ID of the created transaction is also unique per occurrence.
+// A schedule whose transaction is invalid (e.g. the account title was deleted) is disabled with LastError.
+func RunRecurringTransactions(now time.Time) (int, error) {
+	var recurrings []model.RecurringTransaction
+	tx := DB.Begin()
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Preload("SubTransactions", func(db *gorm.DB) *gorm.DB { return db.Order("recurring_sub_transaction_id") }).
+		Where("is_enabled AND next_occurrence <= ?", now).
+		Order("next_occurrence").Limit(recurringBatchSize).Find(&recurrings).Error
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("No Recurring Transactions", err)
+		return 0, err
+	}
+
+	created := 0
+	for idx := range recurrings {
+		n, err := runRecurringTransaction(tx, &recurrings[idx], now)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		created += n
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		fmt.Println("Transaction Commit Error: ", err)
+		return 0, err
+	}
+
+	return created, nil
+}
+
+func runRecurringTransaction(tx *gorm.DB, recurring *model.RecurringTransaction, now time.Time) (int, error) {
+	created := 0
+	for count := 0; count < maxRecurringCatchUp; count++ {
+		if recurring.NextOccurrence == nil || recurring.NextOccurrence.After(now) {
+			break
+		}
+		occurrence := recurring.NextOccurrence.In(time.Local)
+		externalId := recurringExternalIdOf(recurring, occurrence)
+
+		var exists int64
+		err := tx.Model(&model.Transaction{}).Where("book_id = ? AND external_id = ?", recurring.BookId, externalId).Count(&exists).Error
+		if err != nil {
+			fmt.Println("Transaction could not found: ", err)
+			return 0, err
+		}
+
+		if exists == 0 {
+			transaction := model.Transaction{
+				BookId:          recurring.BookId,
+				Description:     recurring.Description,
+				OccurredAt:      occurrence,
+				SubTransactions: recurringSubTransactionsOf(recurring),
+				ExternalId:      externalId,
+				Status:          recurringStatusOf(recurring),
+			}
+
+			err = createTransaction(tx, &transaction)
+			var validationError *TransactionValidationError
+			if errors.As(err, &validationError) {
+				// Nothing was written for the invalid transaction
+				recurring.IsEnabled = false
+				recurring.LastError = fmt.Sprintf("%s: %s", occurrence.Format("2006-01-02"), validationError.Error())
+				break
+			}
+			if err != nil {
+				return 0, err
+			}
+			created++
+		}
+
+		recurring.LastOccurrence = &occurrence
+		next, err := nextOccurrenceOf(recurring)
+		if err != nil {
+			recurring.IsEnabled = false
+			recurring.LastError = err.Error()
+			break
+		}
+		recurring.NextOccurrence = next
+	}
+
+	err := tx.Model(recurring).Select("is_enabled", "next_occurrence", "last_occurrence", "last_error").Updates(recurring).Error
+	if err != nil {
+		fmt.Println("Recurring Transaction could not update: ", err)
+		return 0, err
+	}
+
+	return created, nil
+}
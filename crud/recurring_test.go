@@ -0,0 +1,48 @@
+package crud
+
+import (
+	"testing"
+	"time"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+)
+
+func TestNextOccurrenceOfStartsAtAnyTime(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	local := time.Local
+	time.Local = jst
+	t.Cleanup(func() { time.Local = local })
+
+	lastOccurrence := time.Date(2024, 1, 27, 0, 0, 0, 0, jst)
+	cases := []struct {
+		name           string
+		startsAt       time.Time
+		lastOccurrence *time.Time
+		want           time.Time
+	}{
+		{"local midnight", time.Date(2024, 1, 27, 0, 0, 0, 0, jst), nil, time.Date(2024, 1, 27, 0, 0, 0, 0, jst)},
+		{"local morning", time.Date(2024, 1, 27, 9, 0, 0, 0, jst), nil, time.Date(2024, 1, 27, 0, 0, 0, 0, jst)},
+		{"UTC midnight", time.Date(2024, 1, 27, 0, 0, 0, 0, time.UTC), nil, time.Date(2024, 1, 27, 0, 0, 0, 0, jst)},
+		{"UTC of the day before", time.Date(2024, 1, 26, 20, 0, 0, 0, time.UTC), nil, time.Date(2024, 1, 27, 0, 0, 0, 0, jst)},
+		{"after the first occurrence", time.Date(2024, 1, 27, 9, 0, 0, 0, jst), &lastOccurrence, time.Date(2024, 2, 27, 0, 0, 0, 0, jst)},
+		{"day after the occurrence", time.Date(2024, 1, 28, 0, 0, 0, 0, jst), nil, time.Date(2024, 2, 27, 0, 0, 0, 0, jst)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			recurring := model.RecurringTransaction{
+				Rule:           "FREQ=MONTHLY;BYMONTHDAY=27",
+				StartsAt:       tc.startsAt,
+				LastOccurrence: tc.lastOccurrence,
+			}
+
+			next, err := nextOccurrenceOf(&recurring)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if next == nil || !next.Equal(tc.want) {
+				t.Errorf("next = %v, want %v", next, tc.want)
+			}
+		})
+	}
+}
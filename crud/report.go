@@ -0,0 +1,363 @@
+package crud
+
+import (
+	"fmt"
+	"time"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+	"gorm.io/gorm"
+)
+
+// Debit and credit totals of an account title, split at the start of a period
+type subTransactionSum struct {
+	AccountTitleId uint64
+	OpeningDebit   int64
+	OpeningCredit  int64
+	PeriodDebit    int64
+	PeriodCredit   int64
+}
+
+func isDebitNormal(accountTitle *model.AccountTitle) bool {
+	return model.NormalBalanceOf(accountTitle.Category, accountTitle.IsContra) == model.BalanceSideDebit
+}
+
+// Returns how much the balance of the account title moves by the entry
+func signedAmount(accountTitle *model.AccountTitle, isDebit bool, amount int64) int64 {
+	if isDebit == isDebitNormal(accountTitle) {
+		return amount
+	}
+	return -amount
+}
+
+// Sums sub transactions of the posted transactions of the book per account title.
+// Entries before from are opening, entries in [from, to) are the period.
+// A zero to means no upper bound.
+func sumSubTransactions(db *gorm.DB, bookId string, from time.Time, to time.Time) (map[uint64]subTransactionSum, error) {
+	var sums []subTransactionSum
+
+	q := db.Table("sub_transactions").Select(`sub_transactions.account_title_id,
+		COALESCE(SUM(CASE WHEN transactions.occurred_at < @from AND sub_transactions.is_debit THEN sub_transactions.amount END), 0) AS opening_debit,
+		COALESCE(SUM(CASE WHEN transactions.occurred_at < @from AND NOT sub_transactions.is_debit THEN sub_transactions.amount END), 0) AS opening_credit,
+		COALESCE(SUM(CASE WHEN transactions.occurred_at >= @from AND sub_transactions.is_debit THEN sub_transactions.amount END), 0) AS period_debit,
+		COALESCE(SUM(CASE WHEN transactions.occurred_at >= @from AND NOT sub_transactions.is_debit THEN sub_transactions.amount END), 0) AS period_credit`,
+		map[string]interface{}{"from": from}).
+		Joins("JOIN transactions ON transactions.transaction_id = sub_transactions.transaction_id AND transactions.book_id = sub_transactions.book_id").
+		Where("sub_transactions.book_id = ? AND transactions.status = ?", bookId, model.TransactionStatusPosted)
+	if !to.IsZero() {
+		q = q.Where("transactions.occurred_at < ?", to)
+	}
+	err := q.Group("sub_transactions.account_title_id").Scan(&sums).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[uint64]subTransactionSum, len(sums))
+	for _, sum := range sums {
+		ret[sum.AccountTitleId] = sum
+	}
+
+	return ret, nil
+}
+
+// Balance of an account title at the start and the end of a period
+type accountTitleBalance struct {
+	AccountTitle model.AccountTitle
+	Sum          subTransactionSum
+	Opening      int64
+	Closing      int64
+}
+
+func getAccountTitleBalances(db *gorm.DB, bookId string, from time.Time, to time.Time) ([]accountTitleBalance, error) {
+	var accountTitles []model.AccountTitle
+	err := db.Where(&model.AccountTitle{BookId: bookId}).Order("sort_order, code, account_title_id").Find(&accountTitles).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sums, err := sumSubTransactions(db, bookId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]accountTitleBalance, 0, len(accountTitles))
+	for _, accountTitle := range accountTitles {
+		sum := sums[accountTitle.AccountTitleId]
+		opening := accountTitle.AmountBase +
+			signedAmount(&accountTitle, true, sum.OpeningDebit) +
+			signedAmount(&accountTitle, false, sum.OpeningCredit)
+		closing := opening +
+			signedAmount(&accountTitle, true, sum.PeriodDebit) +
+			signedAmount(&accountTitle, false, sum.PeriodCredit)
+
+		balances = append(balances, accountTitleBalance{
+			AccountTitle: accountTitle,
+			Sum:          sum,
+			Opening:      opening,
+			Closing:      closing,
+		})
+	}
+
+	return balances, nil
+}
+
+func GetTrialBalance(book *model.Book, from time.Time, to time.Time) (model.TrialBalance, error) {
+	balances, err := getAccountTitleBalances(DB, book.BookId, from, to)
+	if err != nil {
+		fmt.Println("Balances could not calculated: ", err)
+		return model.TrialBalance{}, err
+	}
+
+	trialBalance := model.TrialBalance{
+		BookId: book.BookId,
+		From:   from,
+		To:     to,
+		Rows:   []model.TrialBalanceRow{},
+	}
+	for _, balance := range balances {
+		accountTitle := &balance.AccountTitle
+		trialBalance.Rows = append(trialBalance.Rows, model.TrialBalanceRow{
+			AccountTitleId: accountTitle.AccountTitleId,
+			Code:           accountTitle.Code,
+			Name:           accountTitle.Name,
+			Category:       accountTitle.Category,
+			OpeningBalance: balance.Opening,
+			Debit:          balance.Sum.PeriodDebit,
+			Credit:         balance.Sum.PeriodCredit,
+			ClosingBalance: balance.Closing,
+		})
+
+		trialBalance.TotalDebit += balance.Sum.PeriodDebit
+		trialBalance.TotalCredit += balance.Sum.PeriodCredit
+
+		// Balances with the opposite sign are on the other side
+		if opening := signedAmount(accountTitle, true, balance.Opening); opening >= 0 {
+			trialBalance.OpeningDebitBalance += opening
+		} else {
+			trialBalance.OpeningCreditBalance -= opening
+		}
+		if closing := signedAmount(accountTitle, true, balance.Closing); closing >= 0 {
+			trialBalance.ClosingDebitBalance += closing
+		} else {
+			trialBalance.ClosingCreditBalance -= closing
+		}
+	}
+
+	trialBalance.Balanced = trialBalance.TotalDebit == trialBalance.TotalCredit &&
+		trialBalance.OpeningDebitBalance == trialBalance.OpeningCreditBalance &&
+		trialBalance.ClosingDebitBalance == trialBalance.ClosingCreditBalance
+
+	return trialBalance, nil
+}
+
+// Returns the amount as a contribution to a group on the debit or credit side.
+// e.g. 減価償却累計額 reduces the assets and 事業主貸 reduces the equity.
+func amountInGroup(accountTitle *model.AccountTitle, groupIsDebit bool, amount int64) int64 {
+	if isDebitNormal(accountTitle) == groupIsDebit {
+		return amount
+	}
+	return -amount
+}
+
+func GetBalanceSheet(book *model.Book, to time.Time) (model.BalanceSheet, error) {
+	balances, err := getAccountTitleBalances(DB, book.BookId, time.Time{}, to)
+	if err != nil {
+		fmt.Println("Balances could not calculated: ", err)
+		return model.BalanceSheet{}, err
+	}
+
+	balanceSheet := model.BalanceSheet{
+		BookId:      book.BookId,
+		To:          to,
+		Assets:      []model.StatementRow{},
+		Liabilities: []model.StatementRow{},
+		Equity:      []model.StatementRow{},
+	}
+	for _, balance := range balances {
+		accountTitle := &balance.AccountTitle
+		row := model.StatementRow{
+			AccountTitleId: accountTitle.AccountTitleId,
+			Code:           accountTitle.Code,
+			Name:           accountTitle.Name,
+			Category:       accountTitle.Category,
+			Amount:         balance.Closing,
+		}
+
+		switch accountTitle.Category {
+		case model.AccountCategoryAsset:
+			balanceSheet.Assets = append(balanceSheet.Assets, row)
+			balanceSheet.TotalAssets += amountInGroup(accountTitle, true, balance.Closing)
+		case model.AccountCategoryLiability:
+			balanceSheet.Liabilities = append(balanceSheet.Liabilities, row)
+			balanceSheet.TotalLiabilities += amountInGroup(accountTitle, false, balance.Closing)
+		case model.AccountCategoryEquity:
+			balanceSheet.Equity = append(balanceSheet.Equity, row)
+			balanceSheet.TotalEquity += amountInGroup(accountTitle, false, balance.Closing)
+		case model.AccountCategoryRevenue, model.AccountCategoryExpense:
+			balanceSheet.NetIncome += amountInGroup(accountTitle, false, balance.Closing)
+		}
+	}
+
+	balanceSheet.TotalLiabilitiesAndEquity = balanceSheet.TotalLiabilities + balanceSheet.TotalEquity + balanceSheet.NetIncome
+	balanceSheet.Balanced = balanceSheet.TotalAssets == balanceSheet.TotalLiabilitiesAndEquity
+
+	return balanceSheet, nil
+}
+
+func GetProfitAndLoss(book *model.Book, from time.Time, to time.Time) (model.ProfitAndLoss, error) {
+	balances, err := getAccountTitleBalances(DB, book.BookId, from, to)
+	if err != nil {
+		fmt.Println("Balances could not calculated: ", err)
+		return model.ProfitAndLoss{}, err
+	}
+
+	profitAndLoss := model.ProfitAndLoss{
+		BookId:   book.BookId,
+		From:     from,
+		To:       to,
+		Revenues: []model.StatementRow{},
+		Expenses: []model.StatementRow{},
+	}
+	for _, balance := range balances {
+		accountTitle := &balance.AccountTitle
+		// Only the movement in the period counts
+		amount := balance.Closing - balance.Opening
+		row := model.StatementRow{
+			AccountTitleId: accountTitle.AccountTitleId,
+			Code:           accountTitle.Code,
+			Name:           accountTitle.Name,
+			Category:       accountTitle.Category,
+			Amount:         amount,
+		}
+
+		switch accountTitle.Category {
+		case model.AccountCategoryRevenue:
+			profitAndLoss.Revenues = append(profitAndLoss.Revenues, row)
+			profitAndLoss.TotalRevenue += amountInGroup(accountTitle, false, amount)
+		case model.AccountCategoryExpense:
+			profitAndLoss.Expenses = append(profitAndLoss.Expenses, row)
+			profitAndLoss.TotalExpense += amountInGroup(accountTitle, true, amount)
+		}
+	}
+
+	profitAndLoss.NetIncome = profitAndLoss.TotalRevenue - profitAndLoss.TotalExpense
+
+	return profitAndLoss, nil
+}
+
+func GetLedger(book *model.Book, accountTitleId uint64, from time.Time, to time.Time) (model.Ledger, error) {
+	ledger, err := GetLedgerOpening(book, accountTitleId, from, to)
+	if err != nil {
+		return model.Ledger{}, err
+	}
+
+	err = ExportLedgerEntries(book, &ledger, func(entries []model.LedgerEntry) error {
+		ledger.Entries = append(ledger.Entries, entries...)
+		return nil
+	})
+	if err != nil {
+		return model.Ledger{}, err
+	}
+
+	return ledger, nil
+}
+
+// Ledger of the period without the entries, which ExportLedgerEntries adds
+func GetLedgerOpening(book *model.Book, accountTitleId uint64, from time.Time, to time.Time) (model.Ledger, error) {
+	accountTitle, err := GetAccountTitle(book, accountTitleId)
+	if err != nil {
+		fmt.Println("Account Title not found: ", err)
+		return model.Ledger{}, err
+	}
+
+	sums, err := sumSubTransactions(DB, book.BookId, from, to)
+	if err != nil {
+		fmt.Println("Sub Transactions could not summed: ", err)
+		return model.Ledger{}, err
+	}
+	sum := sums[accountTitleId]
+	opening := accountTitle.AmountBase +
+		signedAmount(&accountTitle, true, sum.OpeningDebit) +
+		signedAmount(&accountTitle, false, sum.OpeningCredit)
+
+	return model.Ledger{
+		BookId:         book.BookId,
+		AccountTitle:   accountTitle,
+		From:           from,
+		To:             to,
+		OpeningBalance: opening,
+		Entries:        []model.LedgerEntry{},
+		ClosingBalance: opening,
+	}, nil
+}
+
+// Entries of the sub transactions of the ledger's account title with the running balance.
+// The totals and the closing balance of the ledger are added up.
+func ledgerEntriesOf(book *model.Book, ledger *model.Ledger, subTransactions []model.SubTransaction) ([]model.LedgerEntry, error) {
+	transactionIds := make([]uint64, 0, len(subTransactions))
+	for _, subTransaction := range subTransactions {
+		transactionIds = append(transactionIds, subTransaction.TransactionId)
+	}
+
+	var lines []model.SubTransaction
+	if len(transactionIds) > 0 {
+		err := DB.Preload("AccountTitle").Where("book_id = ? AND transaction_id IN ?", book.BookId, transactionIds).Order("sub_transaction_id").Find(&lines).Error
+		if err != nil {
+			fmt.Println("No Sub Transactions", err)
+			return nil, err
+		}
+	}
+	linesOfTransaction := make(map[uint64][]model.SubTransaction)
+	for _, line := range lines {
+		linesOfTransaction[line.TransactionId] = append(linesOfTransaction[line.TransactionId], line)
+	}
+
+	entries := make([]model.LedgerEntry, 0, len(subTransactions))
+	for _, subTransaction := range subTransactions {
+		entry := model.LedgerEntry{
+			SubTransactionId: subTransaction.SubTransactionId,
+			TransactionId:    subTransaction.TransactionId,
+			CounterAccounts:  counterAccountsOf(&subTransaction, linesOfTransaction[subTransaction.TransactionId]),
+		}
+		if subTransaction.Transaction != nil {
+			entry.OccurredAt = subTransaction.Transaction.OccurredAt
+			entry.Description = subTransaction.Transaction.Description
+		}
+
+		if subTransaction.IsDebit {
+			entry.Debit = subTransaction.Amount
+			ledger.TotalDebit += subTransaction.Amount
+		} else {
+			entry.Credit = subTransaction.Amount
+			ledger.TotalCredit += subTransaction.Amount
+		}
+		ledger.ClosingBalance += signedAmount(&ledger.AccountTitle, subTransaction.IsDebit, subTransaction.Amount)
+		entry.Balance = ledger.ClosingBalance
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Counter accounts are the lines of the other side in the same transaction
+func counterAccountsOf(subTransaction *model.SubTransaction, lines []model.SubTransaction) []model.LedgerCounterAccount {
+	counterAccounts := []model.LedgerCounterAccount{}
+	for _, line := range lines {
+		if line.IsDebit == subTransaction.IsDebit {
+			continue
+		}
+
+		counterAccount := model.LedgerCounterAccount{
+			AccountTitleId: line.AccountTitleId,
+			Amount:         line.Amount,
+		}
+		if line.AccountTitle != nil {
+			counterAccount.Code = line.AccountTitle.Code
+			counterAccount.Name = line.AccountTitle.Name
+		}
+		counterAccounts = append(counterAccounts, counterAccount)
+	}
+
+	return counterAccounts
+}
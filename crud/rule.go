@@ -0,0 +1,293 @@
+package crud
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+	"gorm.io/gorm"
+)
+
+const ruleDryRunBatchSize = 500
+
+type compiledRule struct {
+	model.Rule
+	pattern *regexp.Regexp
+}
+
+func compileRule(rule *model.Rule) (compiledRule, error) {
+	compiled := compiledRule{Rule: *rule}
+	if rule.DescriptionPattern != "" {
+		pattern, err := regexp.Compile(rule.DescriptionPattern)
+		if err != nil {
+			return compiledRule{}, err
+		}
+		compiled.pattern = pattern
+	}
+	return compiled, nil
+}
+
+// Checks the pattern, the amount range and the account titles of the rule
+func validateRule(db *gorm.DB, rule *model.Rule) error {
+	if _, err := compileRule(rule); err != nil {
+		return InvalidRuleError
+	}
+	if rule.MinAmount != nil && rule.MaxAmount != nil && *rule.MinAmount > *rule.MaxAmount {
+		return InvalidRuleError
+	}
+
+	accountTitleIds := []uint64{rule.CounterAccountTitleId}
+	if rule.SourceAccountTitleId != nil {
+		if *rule.SourceAccountTitleId == rule.CounterAccountTitleId {
+			return InvalidRuleError
+		}
+		accountTitleIds = append(accountTitleIds, *rule.SourceAccountTitleId)
+	}
+
+	var count int64
+	err := db.Model(&model.AccountTitle{}).Where("book_id = ? AND account_title_id IN ?", rule.BookId, accountTitleIds).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count != int64(len(accountTitleIds)) {
+		return InvalidRuleError
+	}
+
+	return nil
+}
+
+func CreateRule(rule *model.Rule) error {
+	err := validateRule(DB, rule)
+	if err != nil {
+		return err
+	}
+
+	err = DB.Create(rule).Error
+	if err != nil {
+		fmt.Println("Rule could not create: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func GetRule(book *model.Book, ruleId uint64) (model.Rule, error) {
+	var rule model.Rule
+	err := DB.Where(&model.Rule{RuleId: ruleId, BookId: book.BookId}).First(&rule).Error
+
+	if err != nil {
+		fmt.Println("Rule could not found: ", err)
+		return model.Rule{}, err
+	}
+
+	return rule, nil
+}
+
+func GetRules(book *model.Book) (*[]model.Rule, error) {
+	var rules []model.Rule
+	err := DB.Where(&model.Rule{BookId: book.BookId}).Order("priority, rule_id").Find(&rules).Error
+
+	if err != nil {
+		fmt.Println("No Rules", err)
+		return nil, err
+	}
+
+	return &rules, nil
+}
+
+func UpdateRule(rule *model.Rule) error {
+	err := validateRule(DB, rule)
+	if err != nil {
+		return err
+	}
+
+	err = DB.Save(rule).Error
+	if err != nil {
+		fmt.Println("Rule could not update: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func DeleteRule(book *model.Book, ruleId uint64) error {
+	result := DB.Where(&model.Rule{RuleId: ruleId, BookId: book.BookId}).Delete(&model.Rule{})
+
+	if result.Error != nil {
+		fmt.Println("Rule could not delete: ", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Enabled rules of the book in the order of the priority
+func getCompiledRules(db *gorm.DB, bookId string) ([]compiledRule, error) {
+	var rules []model.Rule
+	err := db.Where("book_id = ? AND is_enabled", bookId).Order("priority, rule_id").Find(&rules).Error
+	if err != nil {
+		return nil, err
+	}
+
+	compiledRules := make([]compiledRule, 0, len(rules))
+	for idx := range rules {
+		compiled, err := compileRule(&rules[idx])
+		if err != nil {
+			// Rules are validated on save, so this is a rule of a broken row
+			fmt.Printf("Rule %d could not compiled: %v\n", rules[idx].RuleId, err)
+			continue
+		}
+		compiledRules = append(compiledRules, compiled)
+	}
+
+	return compiledRules, nil
+}
+
+func (rule *compiledRule) matches(description string, source *model.SubTransaction) bool {
+	if rule.SourceAccountTitleId != nil && *rule.SourceAccountTitleId != source.AccountTitleId {
+		return false
+	}
+	if rule.MinAmount != nil && source.Amount < *rule.MinAmount {
+		return false
+	}
+	if rule.MaxAmount != nil && source.Amount > *rule.MaxAmount {
+		return false
+	}
+	if rule.DescriptionContains != "" && !strings.Contains(strings.ToLower(description), strings.ToLower(rule.DescriptionContains)) {
+		return false
+	}
+	if rule.pattern != nil && !rule.pattern.MatchString(description) {
+		return false
+	}
+	return true
+}
+
+func (rule *compiledRule) rewriteDescription(description string) string {
+	if rule.DescriptionRewrite == "" {
+		return description
+	}
+	if rule.pattern == nil {
+		return rule.DescriptionRewrite
+	}
+
+	submatches := rule.pattern.FindStringSubmatchIndex(description)
+	return string(rule.pattern.ExpandString(nil, rule.DescriptionRewrite, description, submatches))
+}
+
+// Adds the line of the counter account to the transaction which has only one line by the first matching rule.
+// Returns false if the transaction is not completed.
+func completeTransactionWithRules(rules []compiledRule, transaction *model.Transaction) bool {
+	if len(transaction.SubTransactions) != 1 {
+		return false
+	}
+	source := transaction.SubTransactions[0]
+
+	for idx := range rules {
+		rule := &rules[idx]
+		if !rule.matches(transaction.Description, &source) {
+			continue
+		}
+
+		transaction.Description = rule.rewriteDescription(transaction.Description)
+		transaction.SubTransactions = append(transaction.SubTransactions, model.SubTransaction{
+			BookId:         transaction.BookId,
+			IsDebit:        !source.IsDebit,
+			AccountTitleId: rule.CounterAccountTitleId,
+			Amount:         source.Amount,
+		})
+		return true
+	}
+
+	return false
+}
+
+// Finds the existing transactions which the rules would complete, without changing them.
+// Only the transactions with one line are completed, like on import and create.
+// ruleId 0 means all the enabled rules, and each transaction is reported with the first matching rule.
+func DryRunRules(book *model.Book, ruleId uint64, filter *TransactionFilter, limit int) ([]model.RuleMatch, error) {
+	var rules []compiledRule
+	if ruleId == 0 {
+		var err error
+		rules, err = getCompiledRules(DB, book.BookId)
+		if err != nil {
+			fmt.Println("No Rules", err)
+			return nil, err
+		}
+	} else {
+		rule, err := GetRule(book, ruleId)
+		if err != nil {
+			return nil, err
+		}
+		compiled, err := compileRule(&rule)
+		if err != nil {
+			return nil, InvalidRuleError
+		}
+		rules = []compiledRule{compiled}
+	}
+
+	matches := []model.RuleMatch{}
+	if len(rules) == 0 {
+		return matches, nil
+	}
+
+	var cursor *transactionCursor
+	for {
+		var transactions []model.Transaction
+		q := filterTransactions(DB, filter).
+			Preload("SubTransactions", func(db *gorm.DB) *gorm.DB { return db.Order("sub_transactions.sub_transaction_id") }).
+			Where(&model.Transaction{BookId: book.BookId}).
+			Where("(SELECT count(*) FROM sub_transactions WHERE sub_transactions.transaction_id = transactions.transaction_id AND sub_transactions.book_id = transactions.book_id) = 1")
+		if cursor != nil {
+			q = q.Where("(transactions.occurred_at, transactions.transaction_id) < (?, ?)", cursor.OccurredAt, cursor.TransactionId)
+		}
+		err := q.Order("transactions.occurred_at DESC, transactions.transaction_id DESC").Limit(ruleDryRunBatchSize).Find(&transactions).Error
+		if err != nil {
+			fmt.Println("No Transactions", err)
+			return nil, err
+		}
+
+		for _, transaction := range transactions {
+			if match, ok := matchTransaction(rules, &transaction); ok {
+				matches = append(matches, match)
+				if limit > 0 && len(matches) >= limit {
+					return matches, nil
+				}
+			}
+		}
+
+		if len(transactions) < ruleDryRunBatchSize {
+			return matches, nil
+		}
+		last := transactions[len(transactions)-1]
+		cursor = &transactionCursor{OccurredAt: last.OccurredAt, TransactionId: last.TransactionId}
+	}
+}
+
+// Matches the transaction as completeTransactionWithRules does, the only line is the source
+func matchTransaction(rules []compiledRule, transaction *model.Transaction) (model.RuleMatch, bool) {
+	if len(transaction.SubTransactions) != 1 {
+		return model.RuleMatch{}, false
+	}
+	source := transaction.SubTransactions[0]
+
+	for idx := range rules {
+		rule := &rules[idx]
+		if !rule.matches(transaction.Description, &source) {
+			continue
+		}
+
+		return model.RuleMatch{
+			RuleId:                 rule.RuleId,
+			Transaction:            *transaction,
+			SourceSubTransactionId: source.SubTransactionId,
+			CounterAccountTitleId:  rule.CounterAccountTitleId,
+			Description:            rule.rewriteDescription(transaction.Description),
+		}, true
+	}
+
+	return model.RuleMatch{}, false
+}
@@ -0,0 +1,44 @@
+package crud
+
+import (
+	"testing"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+)
+
+// The dry run matches only what completeTransactionWithRules would complete
+func TestMatchTransactionOnlyOneLine(t *testing.T) {
+	rules := []compiledRule{{Rule: model.Rule{RuleId: 1, DescriptionContains: "rent", CounterAccountTitleId: 4}}}
+
+	cases := []struct {
+		name            string
+		subTransactions []model.SubTransaction
+		want            bool
+	}{
+		{"one line", []model.SubTransaction{{SubTransactionId: 11, IsDebit: false, AccountTitleId: 2, Amount: 1000}}, true},
+		{"balanced", []model.SubTransaction{
+			{SubTransactionId: 11, IsDebit: true, AccountTitleId: 4, Amount: 1000},
+			{SubTransactionId: 12, IsDebit: false, AccountTitleId: 2, Amount: 1000},
+		}, false},
+		{"no lines", []model.SubTransaction{}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			transaction := model.Transaction{Description: "Rent", SubTransactions: tc.subTransactions}
+			completed := transaction
+			completed.SubTransactions = append([]model.SubTransaction{}, tc.subTransactions...)
+
+			match, ok := matchTransaction(rules, &transaction)
+			if ok != tc.want {
+				t.Fatalf("matched = %v, want %v", ok, tc.want)
+			}
+			if got := completeTransactionWithRules(rules, &completed); got != ok {
+				t.Errorf("completed = %v, matched = %v", got, ok)
+			}
+			if ok && match.SourceSubTransactionId != 11 {
+				t.Errorf("source = %d, want 11", match.SourceSubTransactionId)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package crud
+
+import (
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+	"gorm.io/gorm"
+)
+
+type accountTitleTemplate struct {
+	Code       string
+	Name       string
+	Category   model.AccountCategory
+	IsContra   bool
+	ParentCode string
+	// Equity accounts closed into 元入金 at year end
+	ClosesAtYearEnd bool
+}
+
+type BookTemplate struct {
+	TemplateId    string `json:"template_id"`
+	Name          string `json:"name"`
+	accountTitles []accountTitleTemplate
+}
+
+var bookTemplates = []BookTemplate{
+	{
+		TemplateId: "blue_return",
+		Name:       "青色申告 個人事業主",
+		accountTitles: []accountTitleTemplate{
+			{Code: "1110", Name: "現金", Category: model.AccountCategoryAsset},
+			{Code: "1120", Name: "当座預金", Category: model.AccountCategoryAsset},
+			{Code: "1130", Name: "普通預金", Category: model.AccountCategoryAsset},
+			{Code: "1140", Name: "定期預金", Category: model.AccountCategoryAsset},
+			{Code: "1150", Name: "受取手形", Category: model.AccountCategoryAsset},
+			{Code: "1160", Name: "売掛金", Category: model.AccountCategoryAsset},
+			{Code: "1165", Name: "貸倒引当金", Category: model.AccountCategoryAsset, IsContra: true},
+			{Code: "1170", Name: "有価証券", Category: model.AccountCategoryAsset},
+			{Code: "1180", Name: "棚卸資産", Category: model.AccountCategoryAsset},
+			{Code: "1190", Name: "前払金", Category: model.AccountCategoryAsset},
+			{Code: "1200", Name: "貸付金", Category: model.AccountCategoryAsset},
+			{Code: "1300", Name: "建物", Category: model.AccountCategoryAsset},
+			{Code: "1310", Name: "建物附属設備", Category: model.AccountCategoryAsset},
+			{Code: "1320", Name: "機械装置", Category: model.AccountCategoryAsset},
+			{Code: "1330", Name: "車両運搬具", Category: model.AccountCategoryAsset},
+			{Code: "1340", Name: "工具器具備品", Category: model.AccountCategoryAsset},
+			{Code: "1350", Name: "減価償却累計額", Category: model.AccountCategoryAsset, IsContra: true},
+			{Code: "1360", Name: "土地", Category: model.AccountCategoryAsset},
+			{Code: "1400", Name: "敷金", Category: model.AccountCategoryAsset},
+			{Code: "1900", Name: "事業主貸", Category: model.AccountCategoryEquity, IsContra: true, ClosesAtYearEnd: true},
+			{Code: "2110", Name: "支払手形", Category: model.AccountCategoryLiability},
+			{Code: "2120", Name: "買掛金", Category: model.AccountCategoryLiability},
+			{Code: "2130", Name: "借入金", Category: model.AccountCategoryLiability},
+			{Code: "2140", Name: "未払金", Category: model.AccountCategoryLiability},
+			{Code: "2150", Name: "前受金", Category: model.AccountCategoryLiability},
+			{Code: "2160", Name: "預り金", Category: model.AccountCategoryLiability},
+			{Code: "2900", Name: "事業主借", Category: model.AccountCategoryEquity, ClosesAtYearEnd: true},
+			{Code: "3110", Name: "元入金", Category: model.AccountCategoryEquity},
+			{Code: "4110", Name: "売上高", Category: model.AccountCategoryRevenue},
+			{Code: "4120", Name: "家事消費等", Category: model.AccountCategoryRevenue},
+			{Code: "4130", Name: "雑収入", Category: model.AccountCategoryRevenue},
+			{Code: "5110", Name: "仕入高", Category: model.AccountCategoryExpense},
+			{Code: "6110", Name: "租税公課", Category: model.AccountCategoryExpense},
+			{Code: "6120", Name: "荷造運賃", Category: model.AccountCategoryExpense},
+			{Code: "6130", Name: "水道光熱費", Category: model.AccountCategoryExpense},
+			{Code: "6140", Name: "旅費交通費", Category: model.AccountCategoryExpense},
+			{Code: "6150", Name: "通信費", Category: model.AccountCategoryExpense},
+			{Code: "6160", Name: "広告宣伝費", Category: model.AccountCategoryExpense},
+			{Code: "6170", Name: "接待交際費", Category: model.AccountCategoryExpense},
+			{Code: "6180", Name: "損害保険料", Category: model.AccountCategoryExpense},
+			{Code: "6190", Name: "修繕費", Category: model.AccountCategoryExpense},
+			{Code: "6200", Name: "消耗品費", Category: model.AccountCategoryExpense},
+			{Code: "6210", Name: "減価償却費", Category: model.AccountCategoryExpense},
+			{Code: "6220", Name: "福利厚生費", Category: model.AccountCategoryExpense},
+			{Code: "6230", Name: "給料賃金", Category: model.AccountCategoryExpense},
+			{Code: "6235", Name: "専従者給与", Category: model.AccountCategoryExpense},
+			{Code: "6240", Name: "外注工賃", Category: model.AccountCategoryExpense},
+			{Code: "6250", Name: "利子割引料", Category: model.AccountCategoryExpense},
+			{Code: "6260", Name: "地代家賃", Category: model.AccountCategoryExpense},
+			{Code: "6270", Name: "貸倒金", Category: model.AccountCategoryExpense},
+			{Code: "6280", Name: "支払手数料", Category: model.AccountCategoryExpense},
+			{Code: "6290", Name: "会議費", Category: model.AccountCategoryExpense},
+			{Code: "6300", Name: "新聞図書費", Category: model.AccountCategoryExpense},
+			{Code: "6900", Name: "雑費", Category: model.AccountCategoryExpense},
+		},
+	},
+	{
+		TemplateId: "household",
+		Name:       "家計簿",
+		accountTitles: []accountTitleTemplate{
+			{Code: "1110", Name: "現金", Category: model.AccountCategoryAsset},
+			{Code: "1120", Name: "普通預金", Category: model.AccountCategoryAsset},
+			{Code: "1130", Name: "定期預金", Category: model.AccountCategoryAsset},
+			{Code: "1140", Name: "電子マネー", Category: model.AccountCategoryAsset},
+			{Code: "1150", Name: "証券口座", Category: model.AccountCategoryAsset},
+			{Code: "1160", Name: "立替金", Category: model.AccountCategoryAsset},
+			{Code: "2110", Name: "クレジットカード", Category: model.AccountCategoryLiability},
+			{Code: "2120", Name: "ローン", Category: model.AccountCategoryLiability},
+			{Code: "2130", Name: "未払金", Category: model.AccountCategoryLiability},
+			{Code: "3110", Name: "元入金", Category: model.AccountCategoryEquity},
+			{Code: "4110", Name: "給与", Category: model.AccountCategoryRevenue},
+			{Code: "4120", Name: "賞与", Category: model.AccountCategoryRevenue},
+			{Code: "4130", Name: "副業収入", Category: model.AccountCategoryRevenue},
+			{Code: "4140", Name: "受取利息・配当", Category: model.AccountCategoryRevenue},
+			{Code: "4900", Name: "その他収入", Category: model.AccountCategoryRevenue},
+			{Code: "5100", Name: "食費", Category: model.AccountCategoryExpense},
+			{Code: "5110", Name: "食料品", Category: model.AccountCategoryExpense, ParentCode: "5100"},
+			{Code: "5120", Name: "外食", Category: model.AccountCategoryExpense, ParentCode: "5100"},
+			{Code: "5200", Name: "日用品", Category: model.AccountCategoryExpense},
+			{Code: "5300", Name: "住居費", Category: model.AccountCategoryExpense},
+			{Code: "5310", Name: "家賃", Category: model.AccountCategoryExpense, ParentCode: "5300"},
+			{Code: "5320", Name: "住宅ローン利息", Category: model.AccountCategoryExpense, ParentCode: "5300"},
+			{Code: "5400", Name: "水道光熱費", Category: model.AccountCategoryExpense},
+			{Code: "5410", Name: "電気代", Category: model.AccountCategoryExpense, ParentCode: "5400"},
+			{Code: "5420", Name: "ガス代", Category: model.AccountCategoryExpense, ParentCode: "5400"},
+			{Code: "5430", Name: "水道代", Category: model.AccountCategoryExpense, ParentCode: "5400"},
+			{Code: "5500", Name: "通信費", Category: model.AccountCategoryExpense},
+			{Code: "5510", Name: "携帯電話", Category: model.AccountCategoryExpense, ParentCode: "5500"},
+			{Code: "5520", Name: "インターネット", Category: model.AccountCategoryExpense, ParentCode: "5500"},
+			{Code: "5600", Name: "交通費", Category: model.AccountCategoryExpense},
+			{Code: "5700", Name: "医療費", Category: model.AccountCategoryExpense},
+			{Code: "5800", Name: "教育費", Category: model.AccountCategoryExpense},
+			{Code: "5900", Name: "娯楽費", Category: model.AccountCategoryExpense},
+			{Code: "5910", Name: "交際費", Category: model.AccountCategoryExpense},
+			{Code: "5920", Name: "衣服・美容", Category: model.AccountCategoryExpense},
+			{Code: "5930", Name: "保険料", Category: model.AccountCategoryExpense},
+			{Code: "5940", Name: "税金・社会保険", Category: model.AccountCategoryExpense},
+			{Code: "5990", Name: "雑費", Category: model.AccountCategoryExpense},
+		},
+	},
+	{
+		TemplateId: "corporation",
+		Name:       "一般法人",
+		accountTitles: []accountTitleTemplate{
+			{Code: "1110", Name: "現金", Category: model.AccountCategoryAsset},
+			{Code: "1120", Name: "当座預金", Category: model.AccountCategoryAsset},
+			{Code: "1130", Name: "普通預金", Category: model.AccountCategoryAsset},
+			{Code: "1140", Name: "定期預金", Category: model.AccountCategoryAsset},
+			{Code: "1150", Name: "受取手形", Category: model.AccountCategoryAsset},
+			{Code: "1160", Name: "売掛金", Category: model.AccountCategoryAsset},
+			{Code: "1165", Name: "貸倒引当金", Category: model.AccountCategoryAsset, IsContra: true},
+			{Code: "1170", Name: "有価証券", Category: model.AccountCategoryAsset},
+			{Code: "1180", Name: "商品", Category: model.AccountCategoryAsset},
+			{Code: "1190", Name: "前払費用", Category: model.AccountCategoryAsset},
+			{Code: "1200", Name: "仮払金", Category: model.AccountCategoryAsset},
+			{Code: "1210", Name: "立替金", Category: model.AccountCategoryAsset},
+			{Code: "1220", Name: "未収入金", Category: model.AccountCategoryAsset},
+			{Code: "1230", Name: "仮払消費税等", Category: model.AccountCategoryAsset},
+			{Code: "1300", Name: "建物", Category: model.AccountCategoryAsset},
+			{Code: "1310", Name: "車両運搬具", Category: model.AccountCategoryAsset},
+			{Code: "1320", Name: "工具器具備品", Category: model.AccountCategoryAsset},
+			{Code: "1330", Name: "減価償却累計額", Category: model.AccountCategoryAsset, IsContra: true},
+			{Code: "1340", Name: "土地", Category: model.AccountCategoryAsset},
+			{Code: "1350", Name: "ソフトウェア", Category: model.AccountCategoryAsset},
+			{Code: "1400", Name: "敷金・保証金", Category: model.AccountCategoryAsset},
+			{Code: "1410", Name: "長期貸付金", Category: model.AccountCategoryAsset},
+			{Code: "2110", Name: "支払手形", Category: model.AccountCategoryLiability},
+			{Code: "2120", Name: "買掛金", Category: model.AccountCategoryLiability},
+			{Code: "2130", Name: "短期借入金", Category: model.AccountCategoryLiability},
+			{Code: "2140", Name: "未払金", Category: model.AccountCategoryLiability},
+			{Code: "2150", Name: "未払費用", Category: model.AccountCategoryLiability},
+			{Code: "2160", Name: "未払法人税等", Category: model.AccountCategoryLiability},
+			{Code: "2170", Name: "未払消費税等", Category: model.AccountCategoryLiability},
+			{Code: "2180", Name: "前受金", Category: model.AccountCategoryLiability},
+			{Code: "2190", Name: "預り金", Category: model.AccountCategoryLiability},
+			{Code: "2200", Name: "仮受消費税等", Category: model.AccountCategoryLiability},
+			{Code: "2300", Name: "長期借入金", Category: model.AccountCategoryLiability},
+			{Code: "3110", Name: "資本金", Category: model.AccountCategoryEquity},
+			{Code: "3120", Name: "資本準備金", Category: model.AccountCategoryEquity},
+			{Code: "3130", Name: "利益準備金", Category: model.AccountCategoryEquity},
+			{Code: "3140", Name: "繰越利益剰余金", Category: model.AccountCategoryEquity},
+			{Code: "4110", Name: "売上高", Category: model.AccountCategoryRevenue},
+			{Code: "4120", Name: "受取利息", Category: model.AccountCategoryRevenue},
+			{Code: "4130", Name: "受取配当金", Category: model.AccountCategoryRevenue},
+			{Code: "4140", Name: "雑収入", Category: model.AccountCategoryRevenue},
+			{Code: "5110", Name: "仕入高", Category: model.AccountCategoryExpense},
+			{Code: "6110", Name: "役員報酬", Category: model.AccountCategoryExpense},
+			{Code: "6120", Name: "給料手当", Category: model.AccountCategoryExpense},
+			{Code: "6130", Name: "法定福利費", Category: model.AccountCategoryExpense},
+			{Code: "6140", Name: "福利厚生費", Category: model.AccountCategoryExpense},
+			{Code: "6150", Name: "旅費交通費", Category: model.AccountCategoryExpense},
+			{Code: "6160", Name: "通信費", Category: model.AccountCategoryExpense},
+			{Code: "6170", Name: "広告宣伝費", Category: model.AccountCategoryExpense},
+			{Code: "6180", Name: "交際費", Category: model.AccountCategoryExpense},
+			{Code: "6190", Name: "会議費", Category: model.AccountCategoryExpense},
+			{Code: "6200", Name: "消耗品費", Category: model.AccountCategoryExpense},
+			{Code: "6210", Name: "事務用品費", Category: model.AccountCategoryExpense},
+			{Code: "6220", Name: "水道光熱費", Category: model.AccountCategoryExpense},
+			{Code: "6230", Name: "地代家賃", Category: model.AccountCategoryExpense},
+			{Code: "6240", Name: "保険料", Category: model.AccountCategoryExpense},
+			{Code: "6250", Name: "修繕費", Category: model.AccountCategoryExpense},
+			{Code: "6260", Name: "租税公課", Category: model.AccountCategoryExpense},
+			{Code: "6270", Name: "減価償却費", Category: model.AccountCategoryExpense},
+			{Code: "6280", Name: "支払手数料", Category: model.AccountCategoryExpense},
+			{Code: "6290", Name: "外注費", Category: model.AccountCategoryExpense},
+			{Code: "6900", Name: "雑費", Category: model.AccountCategoryExpense},
+			{Code: "7110", Name: "支払利息", Category: model.AccountCategoryExpense},
+			{Code: "8110", Name: "法人税等", Category: model.AccountCategoryExpense},
+		},
+	},
+}
+
+func GetBookTemplates() []BookTemplate {
+	return bookTemplates
+}
+
+func getBookTemplate(templateId string) (*BookTemplate, error) {
+	for idx := range bookTemplates {
+		if bookTemplates[idx].TemplateId == templateId {
+			return &bookTemplates[idx], nil
+		}
+	}
+
+	return nil, InvalidBookTemplateError
+}
+
+// Creates the account titles of the template in the book.
+// Parents must be listed before their sub accounts.
+func createAccountTitlesFromTemplate(tx *gorm.DB, book *model.Book, template *BookTemplate) error {
+	accountTitleIds := make(map[string]uint64, len(template.accountTitles))
+	for idx, entry := range template.accountTitles {
+		accountTitle := model.AccountTitle{
+			BookId:          book.BookId,
+			Name:            entry.Name,
+			Category:        entry.Category,
+			IsContra:        entry.IsContra,
+			NormalBalance:   model.NormalBalanceOf(entry.Category, entry.IsContra),
+			Code:            entry.Code,
+			SortOrder:       (idx + 1) * 10,
+			ClosesAtYearEnd: entry.ClosesAtYearEnd,
+		}
+		if entry.ParentCode != "" {
+			parentId, ok := accountTitleIds[entry.ParentCode]
+			if !ok {
+				return InvalidParentAccountTitleError
+			}
+			accountTitle.ParentId = &parentId
+		}
+
+		err := tx.Create(&accountTitle).Error
+		if err != nil {
+			return err
+		}
+		accountTitleIds[entry.Code] = accountTitle.AccountTitleId
+	}
+
+	return nil
+}
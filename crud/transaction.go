@@ -1,13 +1,26 @@
 package crud
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	model "github.com/Prokuma/PLAccounting-Backend/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-func CreateBook(user *model.User, book *model.Book) error {
+// Account titles of the template are also created unless templateId is empty
+func CreateBook(user *model.User, book *model.Book, templateId string) error {
+	var template *BookTemplate
+	if templateId != "" {
+		var err error
+		template, err = getBookTemplate(templateId)
+		if err != nil {
+			return err
+		}
+	}
+
 	tx := DB.Begin()
 	result := tx.Create(book)
 
@@ -29,6 +42,15 @@ func CreateBook(user *model.User, book *model.Book) error {
 		return err
 	}
 
+	if template != nil {
+		err = createAccountTitlesFromTemplate(tx, book, template)
+		if err != nil {
+			fmt.Println("Account Titles could not create from template: ", err)
+			tx.Rollback()
+			return err
+		}
+	}
+
 	err = tx.Commit().Error
 
 	if err != nil {
@@ -81,27 +103,60 @@ func GetBookPages(bookId string, dataPerPages int) int64 {
 		return 1
 	}
 
-	if (count % int64(dataPerPages)) == 1 {
-		return count/int64(dataPerPages) + 1
+	if count == 0 {
+		return 1
 	}
-	return count / int64(dataPerPages)
+	return (count + int64(dataPerPages) - 1) / int64(dataPerPages)
 }
 
-func GetAllBooks(user *model.User) (*[]model.Book, error) {
+func GetAllBooks(user *model.User, pagination *Pagination) (*[]model.Book, PageInfo, error) {
 	var books []model.Book
+	var pageInfo PageInfo
 	subQuery := DB.Select("book_id").Where(&model.BookAuthorization{UserId: user.UserId}).Table("book_authorizations")
-	err := DB.Group("book_id").Having("book_id IN (?)", subQuery).Find(&books).Error
+	q := DB.Model(&model.Book{}).Where("book_id IN (?)", subQuery).Session(&gorm.Session{})
 
+	err := q.Count(&pageInfo.Total).Error
 	if err != nil {
 		fmt.Println("Books could not found: ", err)
-		return nil, err
+		return nil, PageInfo{}, err
 	}
 
-	return &books, nil
+	if pagination.Cursor != "" {
+		var cursor bookCursor
+		if err := decodeCursor(pagination.Cursor, &cursor); err != nil {
+			return nil, PageInfo{}, err
+		}
+		q = q.Where("(created_at, book_id) > (?, ?)", cursor.CreatedAt, cursor.BookId)
+	}
+
+	err = q.Order("created_at, book_id").Offset(pagination.offset()).Limit(pagination.fetchLimit()).Find(&books).Error
+	if err != nil {
+		fmt.Println("Books could not found: ", err)
+		return nil, PageInfo{}, err
+	}
+
+	n, hasNext := pagination.hasNext(len(books))
+	books = books[:n]
+	if hasNext {
+		last := books[n-1]
+		pageInfo.NextCursor = encodeCursor(bookCursor{CreatedAt: last.CreatedAt, BookId: last.BookId})
+	}
+
+	return &books, pageInfo, nil
 }
 
 func CreateAccountTitle(title *model.AccountTitle) error {
-	err := DB.Create(title).Error
+	if !title.Category.IsValid() {
+		return InvalidAccountCategoryError
+	}
+	title.NormalBalance = model.NormalBalanceOf(title.Category, title.IsContra)
+
+	err := validateParentAccountTitle(DB, title)
+	if err != nil {
+		return err
+	}
+
+	err = DB.Create(title).Error
 
 	if err != nil {
 		fmt.Println("Account Title could not create: ", err)
@@ -125,7 +180,7 @@ func GetAccountTitle(book *model.Book, accountTitleId uint64) (model.AccountTitl
 func GetAllAccountTitles(book *model.Book) (*[]model.AccountTitle, error) {
 	var accountTitles []model.AccountTitle
 
-	err := DB.Where(&model.AccountTitle{BookId: *&book.BookId}).Order("account_title_id DESC").Find(&accountTitles).Error
+	err := DB.Where(&model.AccountTitle{BookId: *&book.BookId}).Order("sort_order, code, account_title_id").Find(&accountTitles).Error
 
 	if err != nil {
 		fmt.Println("No Account Titles", err)
@@ -135,37 +190,221 @@ func GetAllAccountTitles(book *model.Book) (*[]model.AccountTitle, error) {
 	return &accountTitles, nil
 }
 
-func GetAccountTitles(book *model.Book, dataPerPage int, page int) (*[]model.AccountTitle, error) {
+func GetAccountTitles(book *model.Book, pagination *Pagination) (*[]model.AccountTitle, PageInfo, error) {
 	var accountTitles []model.AccountTitle
+	var pageInfo PageInfo
+	q := DB.Model(&model.AccountTitle{}).Where(&model.AccountTitle{BookId: book.BookId}).Session(&gorm.Session{})
+
+	err := q.Count(&pageInfo.Total).Error
+	if err != nil {
+		fmt.Println("No Account Titles", err)
+		return nil, PageInfo{}, err
+	}
 
-	err := DB.Where(&model.AccountTitle{BookId: *&book.BookId}).Order("account_title_id DESC").Limit(dataPerPage).Offset(dataPerPage * page).Find(&accountTitles).Error
+	if pagination.Cursor != "" {
+		var cursor accountTitleCursor
+		if err := decodeCursor(pagination.Cursor, &cursor); err != nil {
+			return nil, PageInfo{}, err
+		}
+		q = q.Where("(sort_order, code, account_title_id) > (?, ?, ?)", cursor.SortOrder, cursor.Code, cursor.AccountTitleId)
+	}
 
+	err = q.Order("sort_order, code, account_title_id").Offset(pagination.offset()).Limit(pagination.fetchLimit()).Find(&accountTitles).Error
 	if err != nil {
 		fmt.Println("No Account Titles", err)
+		return nil, PageInfo{}, err
+	}
+
+	n, hasNext := pagination.hasNext(len(accountTitles))
+	accountTitles = accountTitles[:n]
+	if hasNext {
+		last := accountTitles[n-1]
+		pageInfo.NextCursor = encodeCursor(accountTitleCursor{SortOrder: last.SortOrder, Code: last.Code, AccountTitleId: last.AccountTitleId})
+	}
+
+	return &accountTitles, pageInfo, nil
+}
+
+func GetAccountTitleTree(book *model.Book) (*[]model.AccountTitleNode, error) {
+	accountTitles, err := GetAllAccountTitles(book)
+	if err != nil {
 		return nil, err
 	}
 
-	return &accountTitles, nil
+	tree := buildAccountTitleTree(*accountTitles)
+	return &tree, nil
 }
 
+// Builds the tree keeping the order of the account titles.
+// Account titles whose parent does not exist become roots.
+func buildAccountTitleTree(accountTitles []model.AccountTitle) []model.AccountTitleNode {
+	exists := make(map[uint64]bool, len(accountTitles))
+	for _, accountTitle := range accountTitles {
+		exists[accountTitle.AccountTitleId] = true
+	}
+
+	var roots []model.AccountTitle
+	children := make(map[uint64][]model.AccountTitle)
+	for _, accountTitle := range accountTitles {
+		if accountTitle.ParentId != nil && exists[*accountTitle.ParentId] {
+			children[*accountTitle.ParentId] = append(children[*accountTitle.ParentId], accountTitle)
+		} else {
+			roots = append(roots, accountTitle)
+		}
+	}
+
+	var build func(accountTitle model.AccountTitle) model.AccountTitleNode
+	build = func(accountTitle model.AccountTitle) model.AccountTitleNode {
+		node := model.AccountTitleNode{
+			AccountTitle:   accountTitle,
+			RolledUpAmount: accountTitle.Amount,
+			Children:       []model.AccountTitleNode{},
+		}
+		for _, child := range children[accountTitle.AccountTitleId] {
+			childNode := build(child)
+			// Contra sub accounts reduce the parent
+			node.RolledUpAmount += amountInGroup(&child, isDebitNormal(&accountTitle), childNode.RolledUpAmount)
+			node.Children = append(node.Children, childNode)
+		}
+		return node
+	}
+
+	tree := []model.AccountTitleNode{}
+	for _, root := range roots {
+		tree = append(tree, build(root))
+	}
+
+	return tree
+}
+
+// Checks that the parent is in the same book and category and is not the title itself or its descendant
+func validateParentAccountTitle(db *gorm.DB, title *model.AccountTitle) error {
+	if title.ParentId == nil {
+		return nil
+	}
+
+	visited := map[uint64]bool{}
+	parentId := *title.ParentId
+	for {
+		if parentId == title.AccountTitleId || visited[parentId] {
+			return InvalidParentAccountTitleError
+		}
+		visited[parentId] = true
+
+		var parent model.AccountTitle
+		err := db.Where(&model.AccountTitle{AccountTitleId: parentId, BookId: title.BookId}).First(&parent).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return InvalidParentAccountTitleError
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(visited) == 1 && parent.Category != title.Category {
+			return InvalidParentAccountTitleError
+		}
+		if parent.ParentId == nil {
+			return nil
+		}
+		parentId = *parent.ParentId
+	}
+}
+
+// Sub accounts of the deleted account title are moved to its parent
 func DeleteAccountTitle(book *model.Book, accountTitleId uint64) error {
-	err := DB.Delete(&model.AccountTitle{AccountTitleId: accountTitleId}).Error
+	var accountTitle model.AccountTitle
+
+	tx := DB.Begin()
+	err := tx.Where(&model.AccountTitle{AccountTitleId: accountTitleId, BookId: book.BookId}).First(&accountTitle).Error
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Account Title not found: ", err)
+		return err
+	}
+
+	err = tx.Model(&model.AccountTitle{}).Where("book_id = ? AND parent_id = ?", book.BookId, accountTitleId).Update("parent_id", accountTitle.ParentId).Error
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Sub Account Titles could not moved: ", err)
+		return err
+	}
+
+	err = tx.Delete(&accountTitle).Error
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Account Title Delete Error: ", err)
+		return err
+	}
 
+	err = tx.Commit().Error
 	if err != nil {
+		fmt.Println("Account Title Commit Error: ", err)
 		return err
 	}
 
 	return nil
 }
 
-func UpdateAccountTitle(title *model.AccountTitle) error {
-	err := DB.Model(&model.AccountTitle{AccountTitleId: title.AccountTitleId, BookId: title.BookId}).Select("*").Updates(title).Error
+// Updates the account title with its row locked, so that the postings at the same time are not lost.
+// amount overwrites the balance if it is not nil, otherwise the balance follows the opening balance and the normal side.
+func UpdateAccountTitle(title *model.AccountTitle, amount *int64) error {
+	if !title.Category.IsValid() {
+		return InvalidAccountCategoryError
+	}
+
+	tx := DB.Begin()
+	var prevTitle model.AccountTitle
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(&model.AccountTitle{AccountTitleId: title.AccountTitleId, BookId: title.BookId}).First(&prevTitle).Error
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Account Title not found: ", err)
+		return err
+	}
+
+	// Entries move the balance the other way when the normal side changes
+	title.NormalBalance = model.NormalBalanceOf(title.Category, title.IsContra)
+	if amount != nil {
+		title.Amount = *amount
+	} else if title.NormalBalance != prevTitle.NormalBalance {
+		title.Amount = title.AmountBase - (prevTitle.Amount - prevTitle.AmountBase)
+	} else {
+		// The balance follows the change of the opening balance
+		title.Amount = prevTitle.Amount + title.AmountBase - prevTitle.AmountBase
+	}
+
+	err = validateParentAccountTitle(tx, title)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// The sub accounts are rolled up into the title, so they must stay in its category
+	if title.Category != prevTitle.Category {
+		var subAccountTitles int64
+		err = tx.Model(&model.AccountTitle{}).Where("book_id = ? AND parent_id = ? AND category <> ?", title.BookId, title.AccountTitleId, title.Category).Count(&subAccountTitles).Error
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if subAccountTitles > 0 {
+			tx.Rollback()
+			return SubAccountTitleCategoryError
+		}
+	}
 
+	err = tx.Model(&model.AccountTitle{AccountTitleId: title.AccountTitleId, BookId: title.BookId}).Select("*").Updates(title).Error
 	if err != nil {
+		tx.Rollback()
 		fmt.Println("Account Title could not updated: ", err)
 		return err
 	}
 
+	err = tx.Commit().Error
+	if err != nil {
+		fmt.Println("Account Title Commit Error: ", err)
+		return err
+	}
+
 	return nil
 }
 
@@ -226,93 +465,320 @@ func DeleteBookAuthorization(authorization *model.BookAuthorization) error {
 	return nil
 }
 
-func CreateBookAndAccountTitleFromBook(year uint, name string, admin *model.User, oldBook *model.Book) error {
-	newBook := model.Book{Year: year, Name: name}
+// Closes the old book and creates the book of the next year.
+// Revenue and expense accounts start from zero and the net income and the owner's accounts (e.g. 事業主貸, 事業主借)
+// are transferred into the retained earnings title (e.g. 元入金, 繰越利益剰余金) of the new book.
+// The retained earnings title is found by name when retainedEarningsTitleId is zero.
+// A book can be rolled over only once.
+func CreateBookAndAccountTitleFromBook(year uint, name string, admin *model.User, oldBook *model.Book, retainedEarningsTitleId uint64) (model.Book, error) {
+	newBook := model.Book{Year: year, Name: name, PreviousBookId: &oldBook.BookId}
 	tx := DB.Begin()
-	result := tx.Create(&newBook)
 
+	// Locks the old book so that rollovers at the same time wait for this one
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(&model.Book{BookId: oldBook.BookId}).First(&model.Book{}).Error
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Book not found: ", err)
+		return model.Book{}, err
+	}
+	var rolledOver int64
+	err = tx.Model(&model.Book{}).Where("previous_book_id = ?", oldBook.BookId).Count(&rolledOver).Error
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Book not found: ", err)
+		return model.Book{}, err
+	}
+	if rolledOver > 0 {
+		tx.Rollback()
+		return model.Book{}, AlreadyRolledOverError
+	}
+
+	balances, err := getAccountTitleBalances(tx, oldBook.BookId, time.Time{}, time.Time{})
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Balances could not calculated: ", err)
+		return model.Book{}, err
+	}
+
+	var retainedEarnings *model.AccountTitle
+	var closedAmount int64
+	for idx := range balances {
+		accountTitle := &balances[idx].AccountTitle
+		isRetainedEarnings := accountTitle.Category == model.AccountCategoryEquity && !isClosedAtYearEnd(accountTitle)
+		if retainedEarningsTitleId != 0 {
+			isRetainedEarnings = isRetainedEarnings && accountTitle.AccountTitleId == retainedEarningsTitleId
+		} else {
+			isRetainedEarnings = isRetainedEarnings && (accountTitle.Name == "元入金" || accountTitle.Name == "繰越利益剰余金")
+		}
+		if isRetainedEarnings && retainedEarnings == nil {
+			retainedEarnings = accountTitle
+		}
+
+		if isClosedAtYearEnd(accountTitle) {
+			closedAmount += amountInGroup(accountTitle, false, balances[idx].Closing)
+		}
+	}
+	if retainedEarnings == nil && (retainedEarningsTitleId != 0 || closedAmount != 0) {
+		tx.Rollback()
+		return model.Book{}, NoRetainedEarningsTitleError
+	}
+
+	result := tx.Create(&newBook)
 	if result.Error != nil {
 		tx.Rollback()
 		fmt.Println("Create New Book was failed: ", result.Error)
-		return result.Error
+		return model.Book{}, result.Error
 	}
 
-	err := DB.Create(&model.BookAuthorization{
+	var oldAuthorizations []model.BookAuthorization
+	err = tx.Where(&model.BookAuthorization{BookId: oldBook.BookId}).Find(&oldAuthorizations).Error
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Book Authorizations not found: ", err)
+		return model.Book{}, err
+	}
+
+	newAuthorizations := []model.BookAuthorization{{
 		BookId:    newBook.BookId,
 		UserId:    *&admin.UserId,
 		Authority: "admin,read,write,update,delete",
-	}).Error
-
-	var oldAccountTtiles []model.AccountTitle
-	var newAccountTitles []model.AccountTitle
-	tx.Where(&model.AccountTitle{BookId: *&oldBook.BookId}).Find(&oldAccountTtiles)
-	for _, accountTitle := range oldAccountTtiles {
-		newAccountTitles = append(newAccountTitles, model.AccountTitle{
-			BookId:     newBook.BookId,
-			Name:       accountTitle.Name,
-			Amount:     accountTitle.Amount, // 繰越
-			AmountBase: accountTitle.Amount, // 繰越
-			Type:       accountTitle.Type,
+	}}
+	for _, authorization := range oldAuthorizations {
+		if authorization.UserId == admin.UserId {
+			continue
+		}
+		newAuthorizations = append(newAuthorizations, model.BookAuthorization{
+			BookId:    newBook.BookId,
+			UserId:    authorization.UserId,
+			Authority: authorization.Authority,
 		})
 	}
-	err = tx.Create(&newAccountTitles).Error
-
+	err = tx.Create(&newAuthorizations).Error
 	if err != nil {
 		tx.Rollback()
-		fmt.Println("Create New Account titles was failed: ", err)
-		return err
+		fmt.Println("Authorization could not create: ", err)
+		return model.Book{}, err
 	}
 
-	err = tx.Commit().Error
+	newAccountTitleIds := make(map[uint64]uint64, len(balances))
+	for _, balance := range balances {
+		accountTitle := balance.AccountTitle
+
+		// 繰越
+		amount := balance.Closing
+		if isClosedAtYearEnd(&accountTitle) {
+			amount = 0
+		}
+		if retainedEarnings != nil && accountTitle.AccountTitleId == retainedEarnings.AccountTitleId {
+			amount += closedAmount
+		}
+
+		newAccountTitle := model.AccountTitle{
+			BookId:          newBook.BookId,
+			Name:            accountTitle.Name,
+			Amount:          amount,
+			AmountBase:      amount,
+			Category:        accountTitle.Category,
+			IsContra:        accountTitle.IsContra,
+			NormalBalance:   accountTitle.NormalBalance,
+			Code:            accountTitle.Code,
+			SortOrder:       accountTitle.SortOrder,
+			ClosesAtYearEnd: accountTitle.ClosesAtYearEnd,
+		}
+		err = tx.Create(&newAccountTitle).Error
+		if err != nil {
+			tx.Rollback()
+			fmt.Println("Create New Account titles was failed: ", err)
+			return model.Book{}, err
+		}
+		newAccountTitleIds[accountTitle.AccountTitleId] = newAccountTitle.AccountTitleId
+	}
+
+	for _, balance := range balances {
+		if balance.AccountTitle.ParentId == nil {
+			continue
+		}
+		parentId, ok := newAccountTitleIds[*balance.AccountTitle.ParentId]
+		if !ok {
+			continue
+		}
 
+		err = tx.Model(&model.AccountTitle{}).
+			Where(&model.AccountTitle{AccountTitleId: newAccountTitleIds[balance.AccountTitle.AccountTitleId], BookId: newBook.BookId}).
+			Update("parent_id", parentId).Error
+		if err != nil {
+			tx.Rollback()
+			fmt.Println("Parent of New Account titles could not set: ", err)
+			return model.Book{}, err
+		}
+	}
+
+	err = tx.Commit().Error
 	if err != nil {
 		fmt.Println("Create Book from old Book was failed: ", err)
-		return err
+		return model.Book{}, err
 	}
 
-	return nil
+	return newBook, nil
+}
+
+// Revenues, expenses and the equity accounts like 事業主貸 and 事業主借 do not carry forward to the next year
+func isClosedAtYearEnd(accountTitle *model.AccountTitle) bool {
+	switch accountTitle.Category {
+	case model.AccountCategoryRevenue, model.AccountCategoryExpense:
+		return true
+	case model.AccountCategoryEquity:
+		return accountTitle.ClosesAtYearEnd
+	}
+	return false
 }
 
 func CreateTransaction(transaction *model.Transaction) error {
 	tx := DB.Begin()
-	err := tx.Create(transaction).Error
+	err := createTransaction(tx, transaction)
 	if err != nil {
 		tx.Rollback()
-		fmt.Println("Transaction Create Error: ", err)
 		return err
 	}
 
-	for _, subTransaction := range *&transaction.SubTransactions {
-		var accountTitle model.AccountTitle
-		err = tx.Where(&model.AccountTitle{AccountTitleId: subTransaction.AccountTitleId}).First(&accountTitle).Error
+	err = tx.Commit().Error
+	if err != nil {
+		fmt.Println("Transaction Commit Error: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// Validates and creates the transaction and applies it to the balances in tx unless it is a draft.
+func createTransaction(tx *gorm.DB, transaction *model.Transaction) error {
+	var rules []compiledRule
+	if len(transaction.SubTransactions) == 1 {
+		var err error
+		rules, err = getCompiledRules(tx, transaction.BookId)
 		if err != nil {
-			tx.Rollback()
-			fmt.Println("Account Title not found: ", err)
+			fmt.Println("Rules could not found: ", err)
 			return err
 		}
+	}
+
+	deltas := map[uint64]int64{}
+	err := insertTransaction(tx, transaction, rules, deltas)
+	if err != nil {
+		return err
+	}
+
+	err = applyBalanceDeltas(tx, transaction.BookId, deltas)
+	if err != nil {
+		fmt.Println("Account Title Update Error: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// Validates and creates the transaction, and adds its lines to deltas unless it is a draft.
+// A transaction without the counter account is completed by the rules.
+func insertTransaction(tx *gorm.DB, transaction *model.Transaction, rules []compiledRule, deltas map[uint64]int64) error {
+	if transaction.Status == "" {
+		transaction.Status = model.TransactionStatusPosted
+	}
+
+	completeTransactionWithRules(rules, transaction)
+
+	err := validateSubTransactions(tx, transaction.BookId, transaction.SubTransactions, transaction.Status)
+	if err != nil {
+		fmt.Println("Transaction Validation Error: ", err)
+		return err
+	}
+
+	err = tx.Create(transaction).Error
+	if err != nil {
+		fmt.Println("Transaction Create Error: ", err)
+		return err
+	}
+
+	if transaction.Status != model.TransactionStatusPosted {
+		return nil
+	}
+
+	err = addBalanceDeltas(tx, transaction.BookId, transaction.SubTransactions, 1, deltas)
+	if err != nil {
+		fmt.Println("Account title not found: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// Creates the transactions of the book in one DB transaction, all of them or none of them.
+// Every transaction is validated so that all the invalid ones are reported at once.
+func CreateTransactions(book *model.Book, transactions []model.Transaction) error {
+	var rules []compiledRule
+	itemErrors := []TransactionItemError{}
+	deltas := map[uint64]int64{}
+
+	tx := DB.Begin()
+	for idx := range transactions {
+		transaction := &transactions[idx]
+		transaction.BookId = book.BookId
+		for lineIdx := range transaction.SubTransactions {
+			transaction.SubTransactions[lineIdx].BookId = book.BookId
+		}
 
-		if subTransaction.IsDebit {
-			if accountTitle.Type%2 == 0 {
-				accountTitle.Amount += subTransaction.Amount
-			} else {
-				accountTitle.Amount -= subTransaction.Amount
+		// Rules are loaded once for the batch
+		if len(transaction.SubTransactions) == 1 && rules == nil {
+			var err error
+			rules, err = getCompiledRules(tx, book.BookId)
+			if err != nil {
+				tx.Rollback()
+				fmt.Println("Rules could not found: ", err)
+				return err
 			}
+		}
 
-		} else {
-			if accountTitle.Type%2 == 0 {
-				accountTitle.Amount -= subTransaction.Amount
-			} else {
-				accountTitle.Amount += subTransaction.Amount
+		// The lines of an invalid header are validated as well, so that all the errors of the item are reported at once
+		if ruleErrors := checkTransactionHeader(transaction); len(ruleErrors) > 0 {
+			status := transaction.Status
+			if !status.IsValid() {
+				status = model.TransactionStatusPosted
+			}
+			completeTransactionWithRules(rules, transaction)
+
+			err := validateSubTransactions(tx, book.BookId, transaction.SubTransactions, status)
+			var validationError *TransactionValidationError
+			if errors.As(err, &validationError) {
+				ruleErrors = append(ruleErrors, validationError.Errors...)
+			} else if err != nil {
+				tx.Rollback()
+				return err
 			}
+			itemErrors = append(itemErrors, TransactionItemError{Index: idx, Errors: ruleErrors})
+			continue
 		}
 
-		err = tx.Save(&accountTitle).Error
+		err := insertTransaction(tx, transaction, rules, deltas)
 		if err != nil {
-			fmt.Println("Account Title Update Error: ", err)
-			tx.Rollback()
-			return err
+			var validationError *TransactionValidationError
+			if !errors.As(err, &validationError) {
+				tx.Rollback()
+				return err
+			}
+			// Nothing was written for the invalid transaction, so the DB transaction is still usable
+			itemErrors = append(itemErrors, TransactionItemError{Index: idx, Errors: validationError.Errors})
 		}
 	}
+	if len(itemErrors) > 0 {
+		tx.Rollback()
+		return &TransactionBatchError{Items: itemErrors}
+	}
+
+	err := applyBalanceDeltas(tx, book.BookId, deltas)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Account Title Update Error: ", err)
+		return err
+	}
 
 	err = tx.Commit().Error
 	if err != nil {
@@ -323,149 +789,195 @@ func CreateTransaction(transaction *model.Transaction) error {
 	return nil
 }
 
+// Replaces the sub transactions with transaction.SubTransactions.
+// Lines without an ID are inserted, lines missing from the request are deleted and the others are updated.
+// The status is kept as it is, drafts are posted by PostTransactions.
 func UpdateTransaction(transaction *model.Transaction) error {
 	var prevTransaction model.Transaction
 	tx := DB.Begin()
-	err := tx.Preload("SubTransactions").Where(&model.Transaction{BookId: transaction.BookId, TransactionId: transaction.TransactionId}).First(&prevTransaction).Error
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("SubTransactions").Where(&model.Transaction{BookId: transaction.BookId, TransactionId: transaction.TransactionId}).First(&prevTransaction).Error
 	if err != nil {
 		tx.Rollback()
 		fmt.Println("Transaction not found: ", err)
 		return err
 	}
 
-	for _, subTransaction := range prevTransaction.SubTransactions {
-		var accountTitle model.AccountTitle
-		err = tx.Where(&model.AccountTitle{AccountTitleId: subTransaction.AccountTitleId}).First(&accountTitle).Error
-		if err != nil {
-			tx.Rollback()
-			fmt.Println("Account title not found: ", err)
-			return err
+	for idx := range transaction.SubTransactions {
+		transaction.SubTransactions[idx].BookId = transaction.BookId
+		transaction.SubTransactions[idx].TransactionId = transaction.TransactionId
+	}
+	transaction.Status = prevTransaction.Status
+
+	err = validateSubTransactions(tx, transaction.BookId, transaction.SubTransactions, transaction.Status)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Transaction Validation Error: ", err)
+		return err
+	}
+
+	inserts, updates, deletes, unknownLines := diffSubTransactions(prevTransaction.SubTransactions, transaction.SubTransactions)
+	if len(unknownLines) > 0 {
+		ruleErrors := []TransactionRuleError{}
+		for _, line := range unknownLines {
+			ruleErrors = append(ruleErrors, TransactionRuleError{
+				Line:    line,
+				Rule:    RuleSubTransactionNotFound,
+				Message: fmt.Sprintf("sub transaction %d is not in the transaction", transaction.SubTransactions[line].SubTransactionId),
+			})
 		}
+		tx.Rollback()
+		return &TransactionValidationError{Errors: ruleErrors}
+	}
 
-		if subTransaction.IsDebit {
-			if accountTitle.Type%2 == 0 {
-				accountTitle.Amount -= subTransaction.Amount
-			} else {
-				accountTitle.Amount += subTransaction.Amount
-			}
-		} else {
-			if accountTitle.Type%2 == 0 {
-				accountTitle.Amount += subTransaction.Amount
-			} else {
-				accountTitle.Amount -= subTransaction.Amount
-			}
+	// Balances move by the difference between the previous and the new lines
+	deltas := map[uint64]int64{}
+	if transaction.Status == model.TransactionStatusPosted {
+		err = addBalanceDeltas(tx, prevTransaction.BookId, prevTransaction.SubTransactions, -1, deltas)
+		if err == nil {
+			err = addBalanceDeltas(tx, transaction.BookId, transaction.SubTransactions, 1, deltas)
 		}
+	}
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Account title not found: ", err)
+		return err
+	}
 
-		err = tx.Save(&accountTitle).Error
+	if len(deletes) > 0 {
+		subTransactionIds := make([]uint64, 0, len(deletes))
+		for _, subTransaction := range deletes {
+			subTransactionIds = append(subTransactionIds, subTransaction.SubTransactionId)
+		}
+		err = tx.Where("book_id = ? AND transaction_id = ? AND sub_transaction_id IN ?", transaction.BookId, transaction.TransactionId, subTransactionIds).Delete(&model.SubTransaction{}).Error
 		if err != nil {
-			fmt.Println("Account Title Update Error: ", err)
 			tx.Rollback()
+			fmt.Println("Sub Transaction Delete Error: ", err)
 			return err
 		}
 	}
 
-	for _, subTransaction := range transaction.SubTransactions {
-		err = tx.Updates(&subTransaction).Error
+	for _, subTransaction := range updates {
+		err = tx.Model(&model.SubTransaction{}).
+			Where(&model.SubTransaction{SubTransactionId: subTransaction.SubTransactionId, BookId: transaction.BookId, TransactionId: transaction.TransactionId}).
+			Updates(map[string]interface{}{
+				"is_debit":         subTransaction.IsDebit,
+				"account_title_id": subTransaction.AccountTitleId,
+				"amount":           subTransaction.Amount,
+			}).Error
 		if err != nil {
+			tx.Rollback()
 			fmt.Println("Sub Transaction Update Error: ", err)
+			return err
+		}
+	}
+
+	if len(inserts) > 0 {
+		err = tx.Omit(clause.Associations).Create(&inserts).Error
+		if err != nil {
 			tx.Rollback()
+			fmt.Println("Sub Transaction Create Error: ", err)
 			return err
 		}
 	}
 
-	var newTransaction model.Transaction
-	err = tx.Preload("SubTransactions").Where(&model.Transaction{BookId: transaction.BookId, TransactionId: transaction.TransactionId}).First(&newTransaction).Error
+	err = tx.Model(&model.Transaction{}).
+		Where(&model.Transaction{BookId: transaction.BookId, TransactionId: transaction.TransactionId}).
+		Updates(map[string]interface{}{
+			"description": transaction.Description,
+			"occurred_at": transaction.OccurredAt,
+		}).Error
 	if err != nil {
-		fmt.Println("Get New Transaction Update Error: ", err)
 		tx.Rollback()
+		fmt.Println("Transaction Update Error: ", err)
 		return err
 	}
 
-	err = tx.Updates(transaction).Error
+	err = applyBalanceDeltas(tx, transaction.BookId, deltas)
 	if err != nil {
 		tx.Rollback()
-		fmt.Println("Transaction Update Error: ", err)
+		fmt.Println("Account Title Update Error: ", err)
 		return err
 	}
 
-	for _, subTransaction := range newTransaction.SubTransactions {
-		var accountTitle model.AccountTitle
-		err = tx.Where(&model.AccountTitle{AccountTitleId: subTransaction.AccountTitleId}).First(&accountTitle).Error
-		if err != nil {
-			tx.Rollback()
-			fmt.Println("Account title not found: ", err)
-			return err
+	err = tx.Commit().Error
+	if err != nil {
+		fmt.Println("Transaction Commit Error: ", err)
+		return err
+	}
+
+	// Inserted lines have their IDs now
+	subTransactions := make([]model.SubTransaction, 0, len(transaction.SubTransactions))
+	insertIdx := 0
+	for _, subTransaction := range transaction.SubTransactions {
+		if subTransaction.SubTransactionId == 0 {
+			subTransaction = inserts[insertIdx]
+			insertIdx++
 		}
+		subTransactions = append(subTransactions, subTransaction)
+	}
+	transaction.SubTransactions = subTransactions
 
-		if subTransaction.IsDebit {
-			if accountTitle.Type%2 == 0 {
-				accountTitle.Amount += subTransaction.Amount
-			} else {
-				accountTitle.Amount -= subTransaction.Amount
-			}
-		} else {
-			if accountTitle.Type%2 == 0 {
-				accountTitle.Amount -= subTransaction.Amount
-			} else {
-				accountTitle.Amount += subTransaction.Amount
-			}
+	return nil
+}
+
+// Compares the previous lines with the requested lines.
+// unknownLines are the indexes of requested lines whose ID is not in the previous lines.
+func diffSubTransactions(prev []model.SubTransaction, next []model.SubTransaction) (inserts []model.SubTransaction, updates []model.SubTransaction, deletes []model.SubTransaction, unknownLines []int) {
+	prevOf := make(map[uint64]model.SubTransaction, len(prev))
+	for _, subTransaction := range prev {
+		prevOf[subTransaction.SubTransactionId] = subTransaction
+	}
+
+	kept := make(map[uint64]bool, len(next))
+	for idx, subTransaction := range next {
+		if subTransaction.SubTransactionId == 0 {
+			inserts = append(inserts, subTransaction)
+			continue
 		}
 
-		err = tx.Save(&accountTitle).Error
-		if err != nil {
-			fmt.Println("Account Title Update Error: ", err)
-			tx.Rollback()
-			return err
+		prevSubTransaction, ok := prevOf[subTransaction.SubTransactionId]
+		if !ok || kept[subTransaction.SubTransactionId] {
+			unknownLines = append(unknownLines, idx)
+			continue
+		}
+		kept[subTransaction.SubTransactionId] = true
+
+		if prevSubTransaction.IsDebit != subTransaction.IsDebit ||
+			prevSubTransaction.AccountTitleId != subTransaction.AccountTitleId ||
+			prevSubTransaction.Amount != subTransaction.Amount {
+			updates = append(updates, subTransaction)
 		}
 	}
 
-	err = tx.Commit().Error
-	if err != nil {
-		fmt.Println("Transaction Commit Error: ", err)
-		return err
+	for _, subTransaction := range prev {
+		if !kept[subTransaction.SubTransactionId] {
+			deletes = append(deletes, subTransaction)
+		}
 	}
 
-	return nil
+	return inserts, updates, deletes, unknownLines
 }
 
 func DeleteTransaction(book *model.Book, transactionId uint64) error {
 	var transaction model.Transaction
 
 	tx := DB.Begin()
-	err := tx.Preload("SubTransactions").Where(&model.Transaction{BookId: book.BookId, TransactionId: transactionId}).First(&transaction).Error
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("SubTransactions").Where(&model.Transaction{BookId: book.BookId, TransactionId: transactionId}).First(&transaction).Error
 	if err != nil {
 		tx.Rollback()
 		fmt.Println("Transaction not found: ", err)
 		return err
 	}
 
-	for _, subTransaction := range transaction.SubTransactions {
-		var accountTitle model.AccountTitle
-		err = tx.Where(&model.AccountTitle{AccountTitleId: subTransaction.AccountTitleId}).First(&accountTitle).Error
-		if err != nil {
-			tx.Rollback()
-			fmt.Println("Account title not found: ", err)
-			return err
+	if transaction.Status == model.TransactionStatusPosted {
+		deltas := map[uint64]int64{}
+		err = addBalanceDeltas(tx, transaction.BookId, transaction.SubTransactions, -1, deltas)
+		if err == nil {
+			err = applyBalanceDeltas(tx, transaction.BookId, deltas)
 		}
-
-		if subTransaction.IsDebit {
-			if accountTitle.Type%2 == 0 {
-				accountTitle.Amount -= subTransaction.Amount
-			} else {
-				accountTitle.Amount += subTransaction.Amount
-			}
-		} else {
-			if accountTitle.Type%2 == 0 {
-				accountTitle.Amount += subTransaction.Amount
-			} else {
-				accountTitle.Amount -= subTransaction.Amount
-			}
-		}
-
-		err = tx.Save(&accountTitle).Error
 		if err != nil {
-			fmt.Println("Account Title Update Error: ", err)
 			tx.Rollback()
+			fmt.Println("Account Title Update Error: ", err)
 			return err
 		}
 	}
@@ -493,6 +1005,130 @@ func DeleteTransaction(book *model.Book, transactionId uint64) error {
 	return nil
 }
 
+// Posts the draft transactions to the balances. Transactions already posted are left as they are.
+// A draft with only one line is completed by the rules before it is validated.
+// All the transactions are posted or none of them.
+func PostTransactions(book *model.Book, transactionIds []uint64) ([]model.Transaction, error) {
+	var transactions []model.Transaction
+
+	tx := DB.Begin()
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("SubTransactions", func(db *gorm.DB) *gorm.DB { return db.Order("sub_transactions.sub_transaction_id") }).
+		Where("book_id = ? AND transaction_id IN ?", book.BookId, transactionIds).Find(&transactions).Error
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Transactions not found: ", err)
+		return nil, err
+	}
+	transactionOf := make(map[uint64]*model.Transaction, len(transactions))
+	for idx := range transactions {
+		transactionOf[transactions[idx].TransactionId] = &transactions[idx]
+	}
+
+	rules, err := getCompiledRules(tx, book.BookId)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println("Rules could not found: ", err)
+		return nil, err
+	}
+
+	itemErrors := []TransactionItemError{}
+	drafts := []*model.Transaction{}
+	seen := make(map[uint64]bool, len(transactionIds))
+	for idx, transactionId := range transactionIds {
+		if seen[transactionId] {
+			continue
+		}
+		seen[transactionId] = true
+
+		transaction, ok := transactionOf[transactionId]
+		if !ok {
+			itemErrors = append(itemErrors, TransactionItemError{
+				Index:         idx,
+				TransactionId: transactionId,
+				Errors: []TransactionRuleError{{
+					Line:    -1,
+					Rule:    RuleTransactionNotFound,
+					Message: fmt.Sprintf("transaction %d is not in the book", transactionId),
+				}},
+			})
+			continue
+		}
+		if transaction.Status == model.TransactionStatusPosted {
+			continue
+		}
+
+		if completeTransactionWithRules(rules, transaction) {
+			counterLine := &transaction.SubTransactions[len(transaction.SubTransactions)-1]
+			counterLine.TransactionId = transaction.TransactionId
+			err = tx.Omit(clause.Associations).Create(counterLine).Error
+			if err == nil {
+				err = tx.Model(&model.Transaction{}).
+					Where(&model.Transaction{BookId: book.BookId, TransactionId: transaction.TransactionId}).
+					Update("description", transaction.Description).Error
+			}
+			if err != nil {
+				tx.Rollback()
+				fmt.Println("Transaction Update Error: ", err)
+				return nil, err
+			}
+		}
+
+		err = validateSubTransactions(tx, book.BookId, transaction.SubTransactions, model.TransactionStatusPosted)
+		if err != nil {
+			var validationError *TransactionValidationError
+			if !errors.As(err, &validationError) {
+				tx.Rollback()
+				fmt.Println("Transaction Validation Error: ", err)
+				return nil, err
+			}
+			itemErrors = append(itemErrors, TransactionItemError{
+				Index:         idx,
+				TransactionId: transactionId,
+				Errors:        validationError.Errors,
+			})
+			continue
+		}
+		drafts = append(drafts, transaction)
+	}
+	if len(itemErrors) > 0 {
+		tx.Rollback()
+		return nil, &TransactionBatchError{Items: itemErrors}
+	}
+
+	deltas := map[uint64]int64{}
+	draftIds := make([]uint64, 0, len(drafts))
+	for _, transaction := range drafts {
+		err = addBalanceDeltas(tx, book.BookId, transaction.SubTransactions, 1, deltas)
+		if err != nil {
+			tx.Rollback()
+			fmt.Println("Account title not found: ", err)
+			return nil, err
+		}
+		draftIds = append(draftIds, transaction.TransactionId)
+		transaction.Status = model.TransactionStatusPosted
+	}
+
+	if len(draftIds) > 0 {
+		err = tx.Model(&model.Transaction{}).Where("book_id = ? AND transaction_id IN ?", book.BookId, draftIds).Update("status", model.TransactionStatusPosted).Error
+		if err == nil {
+			err = applyBalanceDeltas(tx, book.BookId, deltas)
+		}
+		if err != nil {
+			tx.Rollback()
+			fmt.Println("Transaction Post Error: ", err)
+			return nil, err
+		}
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		fmt.Println("Transaction Commit Error: ", err)
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
 func GetTransaction(book *model.Book, transactionId uint64) (model.Transaction, error) {
 	var transaction model.Transaction
 	err := DB.Preload("SubTransactions").Preload("SubTransactions.AccountTitle").Where(&model.Transaction{TransactionId: transactionId, BookId: *&book.BookId}).First(&transaction).Error
@@ -505,31 +1141,78 @@ func GetTransaction(book *model.Book, transactionId uint64) (model.Transaction,
 	return transaction, nil
 }
 
-func GetTransactions(book *model.Book, dataPerPage int, page int) (*[]model.Transaction, error) {
+func GetTransactions(book *model.Book, filter *TransactionFilter, pagination *Pagination) (*[]model.Transaction, PageInfo, error) {
 	var transactions []model.Transaction
+	var pageInfo PageInfo
+	q := filterTransactions(DB.Model(&model.Transaction{}), filter).Where(&model.Transaction{BookId: book.BookId}).Session(&gorm.Session{})
 
-	q := DB.Preload("SubTransactions", func(db *gorm.DB) *gorm.DB { return db.Order("sub_transactions.is_debit DESC") }).Preload("SubTransactions.AccountTitle").Where(&model.Transaction{BookId: *&book.BookId}).Order("occurred_at DESC, created_at DESC")
-	err := q.Offset(dataPerPage * page).Limit(dataPerPage).Find(&transactions).Error
+	err := q.Count(&pageInfo.Total).Error
+	if err != nil {
+		fmt.Println("No Transactions", err)
+		return nil, PageInfo{}, err
+	}
+
+	if pagination.Cursor != "" {
+		var cursor transactionCursor
+		if err := decodeCursor(pagination.Cursor, &cursor); err != nil {
+			return nil, PageInfo{}, err
+		}
+		q = q.Where("(transactions.occurred_at, transactions.transaction_id) < (?, ?)", cursor.OccurredAt, cursor.TransactionId)
+	}
 
+	q = q.Preload("SubTransactions", func(db *gorm.DB) *gorm.DB { return db.Order("sub_transactions.is_debit DESC") }).Preload("SubTransactions.AccountTitle").Order("transactions.occurred_at DESC, transactions.transaction_id DESC")
+	err = q.Offset(pagination.offset()).Limit(pagination.fetchLimit()).Find(&transactions).Error
 	if err != nil {
 		fmt.Println("No Transactions", err)
-		return nil, err
+		return nil, PageInfo{}, err
+	}
+
+	n, hasNext := pagination.hasNext(len(transactions))
+	transactions = transactions[:n]
+	if hasNext {
+		last := transactions[n-1]
+		pageInfo.NextCursor = encodeCursor(transactionCursor{OccurredAt: last.OccurredAt, TransactionId: last.TransactionId})
 	}
 
-	return &transactions, nil
+	return &transactions, pageInfo, nil
 }
 
-func GetSubTransactionsFromAccountTitle(book *model.Book, accountTitleId uint64, dataPerPage int, page int) (*[]model.SubTransaction, error) {
+func GetSubTransactionsFromAccountTitle(book *model.Book, accountTitleId uint64, pagination *Pagination) (*[]model.SubTransaction, PageInfo, error) {
 	var subTransactions []model.SubTransaction
+	var pageInfo PageInfo
+	q := DB.Model(&model.SubTransaction{}).
+		Joins("JOIN transactions ON transactions.transaction_id = sub_transactions.transaction_id AND transactions.book_id = sub_transactions.book_id").
+		Where("sub_transactions.book_id = ? AND sub_transactions.account_title_id = ?", book.BookId, accountTitleId).
+		Session(&gorm.Session{})
+
+	err := q.Count(&pageInfo.Total).Error
+	if err != nil {
+		fmt.Println("No Sub Transactions", err)
+		return nil, PageInfo{}, err
+	}
 
-	err := DB.Preload("AccountTitle").Preload("Transaction", func(db *gorm.DB) *gorm.DB {
-		return db.Order("transactions.occurred_at DESC, transactions.created_at DESC")
-	}).Where(&model.SubTransaction{BookId: *&book.BookId, AccountTitleId: accountTitleId}).Offset(dataPerPage * page).Limit(dataPerPage).Find(&subTransactions).Error
+	if pagination.Cursor != "" {
+		var cursor subTransactionCursor
+		if err := decodeCursor(pagination.Cursor, &cursor); err != nil {
+			return nil, PageInfo{}, err
+		}
+		q = q.Where("(transactions.occurred_at, sub_transactions.transaction_id, sub_transactions.sub_transaction_id) < (?, ?, ?)", cursor.OccurredAt, cursor.TransactionId, cursor.SubTransactionId)
+	}
 
+	err = q.Preload("AccountTitle").Preload("Transaction").
+		Order("transactions.occurred_at DESC, sub_transactions.transaction_id DESC, sub_transactions.sub_transaction_id DESC").
+		Offset(pagination.offset()).Limit(pagination.fetchLimit()).Find(&subTransactions).Error
 	if err != nil {
 		fmt.Println("No Sub Transactions", err)
-		return nil, err
+		return nil, PageInfo{}, err
+	}
+
+	n, hasNext := pagination.hasNext(len(subTransactions))
+	subTransactions = subTransactions[:n]
+	if hasNext {
+		last := subTransactions[n-1]
+		pageInfo.NextCursor = encodeCursor(subTransactionCursor{OccurredAt: last.Transaction.OccurredAt, TransactionId: last.TransactionId, SubTransactionId: last.SubTransactionId})
 	}
 
-	return &subTransactions, nil
+	return &subTransactions, pageInfo, nil
 }
@@ -0,0 +1,292 @@
+package crud
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+)
+
+// Account titles of the test cases, AccountTitleId is the index + 1
+var testCategories = []model.AccountCategory{
+	model.AccountCategoryAsset,   // cash
+	model.AccountCategoryAsset,   // bank
+	model.AccountCategoryRevenue, // sales
+	model.AccountCategoryExpense, // expense
+}
+
+const (
+	cash = iota
+	bank
+	sales
+	expense
+)
+
+// id 0 is a new line and ids from 11 are the lines of prev in order
+type testLine struct {
+	id     uint64
+	debit  bool
+	title  int
+	amount int64
+}
+
+const unknownLineId = 99
+
+var updateTransactionCases = []struct {
+	name        string
+	prev        []testLine
+	next        []testLine
+	wantInserts int
+	wantUpdates []uint64
+	wantDeletes []uint64
+	wantUnknown []int
+	// Balance changes per account title, nil if the update is rejected
+	wantDeltas map[int]int64
+}{
+	{
+		name:        "new line inserted",
+		prev:        []testLine{{11, true, cash, 1000}, {12, false, sales, 1000}},
+		next:        []testLine{{11, true, cash, 700}, {0, true, bank, 300}, {12, false, sales, 1000}},
+		wantInserts: 1,
+		wantUpdates: []uint64{11},
+		wantDeltas:  map[int]int64{cash: -300, bank: 300},
+	},
+	{
+		name:        "line deleted",
+		prev:        []testLine{{11, true, cash, 600}, {12, true, bank, 400}, {13, false, sales, 1000}},
+		next:        []testLine{{11, true, cash, 1000}, {13, false, sales, 1000}},
+		wantUpdates: []uint64{11},
+		wantDeletes: []uint64{12},
+		wantDeltas:  map[int]int64{cash: 400, bank: -400},
+	},
+	{
+		name:        "line moved to another account",
+		prev:        []testLine{{11, true, cash, 1000}, {12, false, sales, 1000}},
+		next:        []testLine{{11, true, bank, 1000}, {12, false, sales, 1000}},
+		wantUpdates: []uint64{11},
+		wantDeltas:  map[int]int64{cash: -1000, bank: 1000},
+	},
+	{
+		name:        "lines switched between debit and credit",
+		prev:        []testLine{{11, true, expense, 500}, {12, false, cash, 500}},
+		next:        []testLine{{11, false, expense, 500}, {12, true, cash, 500}},
+		wantUpdates: []uint64{11, 12},
+		wantDeltas:  map[int]int64{expense: -1000, cash: 1000},
+	},
+	{
+		name:        "duplicate line ids",
+		prev:        []testLine{{11, true, cash, 1000}, {12, false, sales, 1000}},
+		next:        []testLine{{11, true, cash, 500}, {11, true, cash, 500}, {12, false, sales, 1000}},
+		wantUpdates: []uint64{11},
+		wantUnknown: []int{1},
+	},
+	{
+		name:        "unknown line id",
+		prev:        []testLine{{11, true, cash, 1000}, {12, false, sales, 1000}},
+		next:        []testLine{{unknownLineId, true, cash, 1000}, {12, false, sales, 1000}},
+		wantDeletes: []uint64{11},
+		wantUnknown: []int{0},
+	},
+	{
+		name:        "insert, update and delete at once",
+		prev:        []testLine{{11, true, cash, 600}, {12, true, bank, 400}, {13, false, sales, 1000}},
+		next:        []testLine{{0, true, expense, 200}, {12, true, bank, 1000}, {13, false, sales, 1200}},
+		wantInserts: 1,
+		wantUpdates: []uint64{12, 13},
+		wantDeletes: []uint64{11},
+		wantDeltas:  map[int]int64{cash: -600, bank: 600, sales: 200, expense: 200},
+	},
+}
+
+func subTransactionsOf(lines []testLine, accountTitleIdOf func(int) uint64, lineIdOf func(uint64) uint64) []model.SubTransaction {
+	subTransactions := make([]model.SubTransaction, 0, len(lines))
+	for _, line := range lines {
+		subTransactions = append(subTransactions, model.SubTransaction{
+			SubTransactionId: lineIdOf(line.id),
+			IsDebit:          line.debit,
+			AccountTitleId:   accountTitleIdOf(line.title),
+			Amount:           line.amount,
+		})
+	}
+	return subTransactions
+}
+
+func idsOf(subTransactions []model.SubTransaction) []uint64 {
+	var ids []uint64
+	for _, subTransaction := range subTransactions {
+		ids = append(ids, subTransaction.SubTransactionId)
+	}
+	return ids
+}
+
+func TestDiffSubTransactions(t *testing.T) {
+	accountTitleOf := map[uint64]*model.AccountTitle{}
+	for idx, category := range testCategories {
+		accountTitleOf[uint64(idx+1)] = &model.AccountTitle{
+			AccountTitleId: uint64(idx + 1),
+			Category:       category,
+			NormalBalance:  model.NormalBalanceOf(category, false),
+		}
+	}
+	accountTitleIdOf := func(title int) uint64 { return uint64(title + 1) }
+	lineIdOf := func(id uint64) uint64 { return id }
+
+	for _, tc := range updateTransactionCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prev := subTransactionsOf(tc.prev, accountTitleIdOf, lineIdOf)
+			next := subTransactionsOf(tc.next, accountTitleIdOf, lineIdOf)
+
+			inserts, updates, deletes, unknownLines := diffSubTransactions(prev, next)
+			if len(inserts) != tc.wantInserts {
+				t.Errorf("inserts = %d, want %d", len(inserts), tc.wantInserts)
+			}
+			if got := idsOf(updates); !reflect.DeepEqual(got, tc.wantUpdates) {
+				t.Errorf("updates = %v, want %v", got, tc.wantUpdates)
+			}
+			if got := idsOf(deletes); !reflect.DeepEqual(got, tc.wantDeletes) {
+				t.Errorf("deletes = %v, want %v", got, tc.wantDeletes)
+			}
+			if !reflect.DeepEqual(unknownLines, tc.wantUnknown) {
+				t.Errorf("unknown lines = %v, want %v", unknownLines, tc.wantUnknown)
+			}
+			if tc.wantDeltas == nil {
+				return
+			}
+
+			// Same as UpdateTransaction: revert the previous lines and post the new lines
+			deltas := map[uint64]int64{}
+			if err := addBalanceDeltasOf(accountTitleOf, prev, -1, deltas); err != nil {
+				t.Fatal(err)
+			}
+			if err := addBalanceDeltasOf(accountTitleOf, next, 1, deltas); err != nil {
+				t.Fatal(err)
+			}
+			for title := range testCategories {
+				if got, want := deltas[accountTitleIdOf(title)], tc.wantDeltas[title]; got != want {
+					t.Errorf("delta of account title %d = %d, want %d", title, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateTransactionBalances(t *testing.T) {
+	setupTestDB(t)
+
+	for _, tc := range updateTransactionCases {
+		t.Run(tc.name, func(t *testing.T) {
+			book, accountTitles := createTestBook(t, testCategories...)
+			accountTitleIdOf := func(title int) uint64 { return accountTitles[title].AccountTitleId }
+
+			transaction := model.Transaction{
+				BookId:          book.BookId,
+				Description:     tc.name,
+				OccurredAt:      time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local),
+				SubTransactions: subTransactionsOf(tc.prev, accountTitleIdOf, func(uint64) uint64 { return 0 }),
+			}
+			if err := CreateTransaction(&transaction); err != nil {
+				t.Fatal(err)
+			}
+
+			before := make([]int64, len(accountTitles))
+			for idx := range accountTitles {
+				before[idx] = amountOf(t, &accountTitles[idx])
+			}
+
+			lineIdOf := func(id uint64) uint64 {
+				switch {
+				case id == 0:
+					return 0
+				case id == unknownLineId:
+					return transaction.SubTransactions[len(transaction.SubTransactions)-1].SubTransactionId + 1000
+				default:
+					return transaction.SubTransactions[id-11].SubTransactionId
+				}
+			}
+			update := model.Transaction{
+				TransactionId:   transaction.TransactionId,
+				BookId:          book.BookId,
+				Description:     tc.name,
+				OccurredAt:      transaction.OccurredAt,
+				SubTransactions: subTransactionsOf(tc.next, accountTitleIdOf, lineIdOf),
+			}
+			err := UpdateTransaction(&update)
+
+			if tc.wantDeltas == nil {
+				var validationError *TransactionValidationError
+				if !errors.As(err, &validationError) {
+					t.Fatalf("err = %v, want TransactionValidationError", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			for idx := range accountTitles {
+				if got, want := amountOf(t, &accountTitles[idx])-before[idx], tc.wantDeltas[idx]; got != want {
+					t.Errorf("balance change of account title %d = %d, want %d", idx, got, want)
+				}
+			}
+		})
+	}
+}
+
+// An item with an invalid header reports the errors of its lines too
+func TestCreateTransactionsReportsAllErrorsOfItem(t *testing.T) {
+	setupTestDB(t)
+
+	book, accountTitles := createTestBook(t, testCategories...)
+	transactions := []model.Transaction{{
+		OccurredAt: time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local),
+		SubTransactions: []model.SubTransaction{
+			{IsDebit: true, AccountTitleId: accountTitles[cash].AccountTitleId, Amount: 0},
+			{IsDebit: false, AccountTitleId: accountTitles[sales].AccountTitleId + 1000, Amount: 1000},
+		},
+	}}
+
+	err := CreateTransactions(&book, transactions)
+	var batchError *TransactionBatchError
+	if !errors.As(err, &batchError) || len(batchError.Items) != 1 {
+		t.Fatalf("err = %v, want TransactionBatchError of 1 item", err)
+	}
+
+	rules := []string{}
+	for _, ruleError := range batchError.Items[0].Errors {
+		rules = append(rules, ruleError.Rule)
+	}
+	want := []string{RuleRequired, RulePositiveAmount, RuleUnbalanced, RuleAccountTitleNotFound}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("rules = %v, want %v", rules, want)
+	}
+}
+
+// The category of a title can not be changed away from the category of its sub accounts
+func TestUpdateAccountTitleCategoryWithSubAccounts(t *testing.T) {
+	setupTestDB(t)
+
+	book, accountTitles := createTestBook(t, model.AccountCategoryAsset)
+	parent := accountTitles[0]
+	child := model.AccountTitle{
+		BookId:        book.BookId,
+		Name:          "child",
+		Category:      model.AccountCategoryAsset,
+		NormalBalance: model.NormalBalanceOf(model.AccountCategoryAsset, false),
+		ParentId:      &parent.AccountTitleId,
+	}
+	if err := CreateAccountTitle(&child); err != nil {
+		t.Fatal(err)
+	}
+
+	update := parent
+	update.Category = model.AccountCategoryExpense
+	if err := UpdateAccountTitle(&update, nil); err != SubAccountTitleCategoryError {
+		t.Fatalf("err = %v, want SubAccountTitleCategoryError", err)
+	}
+
+	update = parent
+	update.Name = "renamed"
+	if err := UpdateAccountTitle(&update, nil); err != nil {
+		t.Fatal(err)
+	}
+}
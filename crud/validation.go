@@ -0,0 +1,173 @@
+package crud
+
+import (
+	"fmt"
+	"strings"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+	"gorm.io/gorm"
+)
+
+// Rules of a journal entry
+const (
+	RuleNoLines                = "no_lines"
+	RulePositiveAmount         = "positive_amount"
+	RuleAccountTitleNotFound   = "account_title_not_found"
+	RuleUnbalanced             = "unbalanced"
+	RuleSubTransactionNotFound = "sub_transaction_not_found"
+	RuleTransactionNotFound    = "transaction_not_found"
+	RuleRequired               = "required"
+	RuleInvalidStatus          = "invalid_status"
+)
+
+// Line is the index of the sub transaction, or -1 for the whole transaction
+type TransactionRuleError struct {
+	Line    int    `json:"line"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type TransactionValidationError struct {
+	Errors []TransactionRuleError `json:"errors"`
+}
+
+func (e *TransactionValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, ruleError := range e.Errors {
+		if ruleError.Line < 0 {
+			messages = append(messages, ruleError.Message)
+		} else {
+			messages = append(messages, fmt.Sprintf("line %d: %s", ruleError.Line, ruleError.Message))
+		}
+	}
+	return "Invalid Transaction: " + strings.Join(messages, ", ")
+}
+
+// Checks the rules which do not need the database.
+// Drafts may be unbalanced until they are posted.
+func checkSubTransactions(subTransactions []model.SubTransaction, status model.TransactionStatus) []TransactionRuleError {
+	ruleErrors := []TransactionRuleError{}
+
+	if len(subTransactions) == 0 {
+		return append(ruleErrors, TransactionRuleError{
+			Line:    -1,
+			Rule:    RuleNoLines,
+			Message: "transaction has no sub transactions",
+		})
+	}
+
+	var debit, credit int64
+	for idx, subTransaction := range subTransactions {
+		if subTransaction.Amount <= 0 {
+			ruleErrors = append(ruleErrors, TransactionRuleError{
+				Line:    idx,
+				Rule:    RulePositiveAmount,
+				Message: fmt.Sprintf("amount must be positive, got %d", subTransaction.Amount),
+			})
+		}
+
+		if subTransaction.IsDebit {
+			debit += subTransaction.Amount
+		} else {
+			credit += subTransaction.Amount
+		}
+	}
+
+	if debit != credit && status != model.TransactionStatusDraft {
+		ruleErrors = append(ruleErrors, TransactionRuleError{
+			Line:    -1,
+			Rule:    RuleUnbalanced,
+			Message: fmt.Sprintf("debit %d does not equal credit %d", debit, credit),
+		})
+	}
+
+	return ruleErrors
+}
+
+// Checks the fields of the transaction which the request of a single transaction checks on binding
+func checkTransactionHeader(transaction *model.Transaction) []TransactionRuleError {
+	ruleErrors := []TransactionRuleError{}
+
+	if transaction.Description == "" {
+		ruleErrors = append(ruleErrors, TransactionRuleError{
+			Line:    -1,
+			Rule:    RuleRequired,
+			Message: "description is required",
+		})
+	}
+	if transaction.OccurredAt.IsZero() {
+		ruleErrors = append(ruleErrors, TransactionRuleError{
+			Line:    -1,
+			Rule:    RuleRequired,
+			Message: "occurred_at is required",
+		})
+	}
+	if transaction.Status != "" && !transaction.Status.IsValid() {
+		ruleErrors = append(ruleErrors, TransactionRuleError{
+			Line:    -1,
+			Rule:    RuleInvalidStatus,
+			Message: fmt.Sprintf("status must be posted or draft, got %s", transaction.Status),
+		})
+	}
+
+	return ruleErrors
+}
+
+// Validates the sub transactions as a journal entry of the book
+func validateSubTransactions(tx *gorm.DB, bookId string, subTransactions []model.SubTransaction, status model.TransactionStatus) error {
+	ruleErrors := checkSubTransactions(subTransactions, status)
+
+	accountTitleIds := make([]uint64, 0, len(subTransactions))
+	for _, subTransaction := range subTransactions {
+		accountTitleIds = append(accountTitleIds, subTransaction.AccountTitleId)
+	}
+
+	var accountTitles []model.AccountTitle
+	if len(accountTitleIds) > 0 {
+		err := tx.Where(&model.AccountTitle{BookId: bookId}).Where("account_title_id IN ?", accountTitleIds).Find(&accountTitles).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	exists := make(map[uint64]bool, len(accountTitles))
+	for _, accountTitle := range accountTitles {
+		exists[accountTitle.AccountTitleId] = true
+	}
+	for idx, subTransaction := range subTransactions {
+		if !exists[subTransaction.AccountTitleId] {
+			ruleErrors = append(ruleErrors, TransactionRuleError{
+				Line:    idx,
+				Rule:    RuleAccountTitleNotFound,
+				Message: fmt.Sprintf("account title %d is not in the book", subTransaction.AccountTitleId),
+			})
+		}
+	}
+
+	if len(ruleErrors) > 0 {
+		return &TransactionValidationError{Errors: ruleErrors}
+	}
+
+	return nil
+}
+
+// Index is the index of the transaction in the request
+type TransactionItemError struct {
+	Index         int                    `json:"index"`
+	TransactionId uint64                 `json:"transaction_id,omitempty"`
+	Errors        []TransactionRuleError `json:"errors"`
+}
+
+// Errors of the transactions in a request for several transactions
+type TransactionBatchError struct {
+	Items []TransactionItemError `json:"items"`
+}
+
+func (e *TransactionBatchError) Error() string {
+	messages := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		validationError := TransactionValidationError{Errors: item.Errors}
+		messages = append(messages, fmt.Sprintf("transaction %d: %s", item.Index, validationError.Error()))
+	}
+	return "Invalid Transactions: " + strings.Join(messages, "; ")
+}
@@ -29,6 +29,20 @@ const docTemplate = `{
                     "Book"
                 ],
                 "summary": "Get All Books",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Limit (all books by default, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "Get All Books",
@@ -204,7 +218,7 @@ const docTemplate = `{
         },
         "/book/{bid}/accountTitle": {
             "get": {
-                "description": "Get All Account Titles",
+                "description": "Get All Account Titles ordered by sort order and code, with the tree of sub accounts",
                 "consumes": [
                     "application/json"
                 ],
@@ -222,6 +236,18 @@ const docTemplate = `{
                         "name": "bid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit of account_titles (all account titles by default, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -425,9 +451,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/book/{bid}/accountTitle/{tid}/transactions": {
+        "/book/{bid}/accountTitle/{tid}/ledger": {
             "get": {
-                "description": "Get Sub Transactions from Account Title",
+                "description": "Get General Ledger (総勘定元帳) of the account title with running balance",
                 "consumes": [
                     "application/json"
                 ],
@@ -435,9 +461,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Sub Transaction"
+                    "Report"
                 ],
-                "summary": "Get Sub Transactions from Account Title",
+                "summary": "Get Ledger",
                 "parameters": [
                     {
                         "type": "string",
@@ -452,11 +478,23 @@ const docTemplate = `{
                         "name": "tid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "From (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Sub Transactions was found",
+                        "description": "Ledger was generated",
                         "schema": {
                             "type": "string"
                         }
@@ -470,19 +508,19 @@ const docTemplate = `{
                 }
             }
         },
-        "/book/{bid}/accountTitle/{tid}/transactions/{pid}": {
+        "/book/{bid}/accountTitle/{tid}/ledger.csv": {
             "get": {
-                "description": "Get Sub Transactions from Account Title with Page",
+                "description": "Export General Ledger (総勘定元帳) of the account title as CSV with running balance",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "text/csv"
                 ],
                 "tags": [
-                    "Sub Transaction"
+                    "Export"
                 ],
-                "summary": "Get Sub Transactions from Account Title with Page",
+                "summary": "Export Ledger",
                 "parameters": [
                     {
                         "type": "string",
@@ -500,15 +538,26 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Page ID",
-                        "name": "pid",
-                        "in": "path",
-                        "required": true
+                        "description": "From (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "shift_jis (default) or utf-8 (with BOM)",
+                        "name": "encoding",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Sub Transactions was found",
+                        "description": "Ledger CSV",
                         "schema": {
                             "type": "string"
                         }
@@ -522,9 +571,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/book/{bid}/transaction": {
+        "/book/{bid}/accountTitle/{tid}/transactions": {
             "get": {
-                "description": "Get Transactions",
+                "description": "Get Sub Transactions from Account Title",
                 "consumes": [
                     "application/json"
                 ],
@@ -532,9 +581,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Transaction"
+                    "Sub Transaction"
                 ],
-                "summary": "Get Transactions",
+                "summary": "Get Sub Transactions from Account Title",
                 "parameters": [
                     {
                         "type": "string",
@@ -542,11 +591,30 @@ const docTemplate = `{
                         "name": "bid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Account Title ID",
+                        "name": "tid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Transactions was found",
+                        "description": "Sub Transactions was found",
                         "schema": {
                             "type": "string"
                         }
@@ -558,9 +626,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Create Transaction",
+            }
+        },
+        "/book/{bid}/accountTitle/{tid}/transactions/{pid}": {
+            "get": {
+                "description": "Get Sub Transactions from Account Title with Page",
                 "consumes": [
                     "application/json"
                 ],
@@ -568,9 +638,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Transaction"
+                    "Sub Transaction"
                 ],
-                "summary": "Create Transaction",
+                "summary": "Get Sub Transactions from Account Title with Page",
                 "parameters": [
                     {
                         "type": "string",
@@ -580,18 +650,35 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Create Transaction",
-                        "name": "transaction",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/endpoint.CreateTransactionRequest"
-                        }
+                        "type": "string",
+                        "description": "Account Title ID",
+                        "name": "tid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Page ID",
+                        "name": "pid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Created Transaction",
+                        "description": "Sub Transactions was found",
                         "schema": {
                             "type": "string"
                         }
@@ -605,9 +692,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/book/{bid}/transaction/page/{pid}": {
+        "/book/{bid}/balanceCheck": {
             "get": {
-                "description": "Get Transactions with Page",
+                "description": "Recompute balances of account titles from the sub transactions and report discrepancies",
                 "consumes": [
                     "application/json"
                 ],
@@ -615,9 +702,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Transaction"
+                    "Balance Check"
                 ],
-                "summary": "Get Transactions with Page",
+                "summary": "Check Balances",
                 "parameters": [
                     {
                         "type": "string",
@@ -625,18 +712,11 @@ const docTemplate = `{
                         "name": "bid",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Page ID",
-                        "name": "pid",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Transactions was found",
+                        "description": "Balances was checked",
                         "schema": {
                             "type": "string"
                         }
@@ -648,11 +728,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/book/{bid}/transaction/{tid}": {
-            "get": {
-                "description": "Get Transaction",
+            },
+            "post": {
+                "description": "Recompute balances of account titles from the sub transactions and overwrite the discrepancies",
                 "consumes": [
                     "application/json"
                 ],
@@ -660,9 +738,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Transaction"
+                    "Balance Check"
                 ],
-                "summary": "Get Transaction",
+                "summary": "Repair Balances",
                 "parameters": [
                     {
                         "type": "string",
@@ -670,18 +748,11 @@ const docTemplate = `{
                         "name": "bid",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Transaction ID",
-                        "name": "tid",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Transaction was found",
+                        "description": "Balances was repaired",
                         "schema": {
                             "type": "string"
                         }
@@ -693,9 +764,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete Transaction",
+            }
+        },
+        "/book/{bid}/bookAuthorization": {
+            "post": {
+                "description": "Create Book Authorization",
                 "consumes": [
                     "application/json"
                 ],
@@ -703,9 +776,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Transaction"
+                    "Book Authorization"
                 ],
-                "summary": "Delete Transaction",
+                "summary": "Create Book Authorization",
                 "parameters": [
                     {
                         "type": "string",
@@ -713,18 +786,11 @@ const docTemplate = `{
                         "name": "bid",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Transaction ID",
-                        "name": "tid",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Transaction was deleted",
+                        "description": "Create Book Authorization",
                         "schema": {
                             "type": "string"
                         }
@@ -736,9 +802,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/book/{bid}/bookAuthorization/{uid}": {
             "patch": {
-                "description": "Update Transaction",
+                "description": "Update Book Authorization",
                 "consumes": [
                     "application/json"
                 ],
@@ -746,9 +814,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Transaction"
+                    "Book Authorization"
                 ],
-                "summary": "Update Transaction",
+                "summary": "Update Book Authorization",
                 "parameters": [
                     {
                         "type": "string",
@@ -759,24 +827,15 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Transaction ID",
-                        "name": "tid",
+                        "description": "User ID",
+                        "name": "uid",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Update Transaction",
-                        "name": "transaction",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/endpoint.UpdateTransactionRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Updated Transaction",
+                        "description": "Update Book Authorization",
                         "schema": {
                             "type": "string"
                         }
@@ -790,33 +849,49 @@ const docTemplate = `{
                 }
             }
         },
-        "/login": {
-            "post": {
-                "description": "Login",
+        "/book/{bid}/export/journal.csv": {
+            "get": {
+                "description": "Export Journal (仕訳帳) of the period as CSV",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "text/csv"
                 ],
                 "tags": [
-                    "User"
+                    "Export"
                 ],
-                "summary": "Login",
+                "summary": "Export Journal",
                 "parameters": [
                     {
-                        "description": "Login",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/endpoint.LoginWithEmailAndPassword"
-                        }
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "From (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "shift_jis (default) or utf-8 (with BOM)",
+                        "name": "encoding",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Login",
+                        "description": "Journal CSV",
                         "schema": {
                             "type": "string"
                         }
@@ -830,28 +905,64 @@ const docTemplate = `{
                 }
             }
         },
-        "/logout": {
-            "get": {
-                "description": "Logout",
+        "/book/{bid}/import/csv": {
+            "post": {
+                "description": "Import bank or credit card statement CSV with the import profile as draft transactions. Deposits are debited to the account title and withdrawals are credited to it. The counter accounts are decided by the rules. Lines imported before are skipped.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Import"
+                ],
+                "summary": "Import Statement CSV",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Statement CSV",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Import Profile ID",
+                        "name": "import_profile_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Account Title ID of the bank or the credit card",
+                        "name": "account_title_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Account Title ID of the counter account when no rule matches",
+                        "name": "counter_account_title_id",
+                        "in": "formData"
+                    }
                 ],
-                "summary": "Logout",
                 "responses": {
                     "200": {
-                        "description": "Logout",
+                        "description": "Statement was imported",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Request is failed",
+                        "description": "Request is failed or Statement is invalid",
                         "schema": {
                             "type": "string"
                         }
@@ -859,22 +970,63 @@ const docTemplate = `{
                 }
             }
         },
-        "/ping": {
-            "get": {
-                "description": "Ping",
+        "/book/{bid}/import/ofx": {
+            "post": {
+                "description": "Import OFX statement as draft transactions. Deposits are debited to the account title and withdrawals are credited to it. The counter accounts are decided by the rules. Lines imported before are skipped by FITID.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Ping"
+                    "Import"
+                ],
+                "summary": "Import OFX",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "OFX",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "utf-8 (default) or shift_jis",
+                        "name": "encoding",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Account Title ID of the bank or the credit card",
+                        "name": "account_title_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Account Title ID of the counter account when no rule matches",
+                        "name": "counter_account_title_id",
+                        "in": "formData"
+                    }
                 ],
-                "summary": "Ping",
                 "responses": {
                     "200": {
-                        "description": "pong",
+                        "description": "Statement was imported",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed or Statement is invalid",
                         "schema": {
                             "type": "string"
                         }
@@ -882,9 +1034,1757 @@ const docTemplate = `{
                 }
             }
         },
-        "/user": {
-            "get": {
-                "description": "Get User",
+        "/book/{bid}/import/qif": {
+            "post": {
+                "description": "Import QIF statement as draft transactions. Deposits are debited to the account title and withdrawals are credited to it. The counter accounts are decided by the rules. Lines imported before are skipped by the date, the amount and the description.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Import"
+                ],
+                "summary": "Import QIF",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "QIF",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "utf-8 (default) or shift_jis",
+                        "name": "encoding",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Account Title ID of the bank or the credit card",
+                        "name": "account_title_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Account Title ID of the counter account when no rule matches",
+                        "name": "counter_account_title_id",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Statement was imported",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed or Statement is invalid",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/book/{bid}/importProfile": {
+            "get": {
+                "description": "Get Column Mapping Profiles of Statement CSV",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Import"
+                ],
+                "summary": "Get Import Profiles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Import Profiles was found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create Column Mapping Profile of Statement CSV",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Import"
+                ],
+                "summary": "Create Import Profile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create Import Profile",
+                        "name": "importProfile",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/endpoint.CreateImportProfileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Import Profile was created",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/book/{bid}/importProfile/{pid}": {
+            "delete": {
+                "description": "Delete Column Mapping Profile of Statement CSV",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Import"
+                ],
+                "summary": "Delete Import Profile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Import Profile ID",
+                        "name": "pid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Import Profile was deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update Column Mapping Profile of Statement CSV",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Import"
+                ],
+                "summary": "Update Import Profile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Import Profile ID",
+                        "name": "pid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update Import Profile",
+                        "name": "importProfile",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/endpoint.UpdateImportProfileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Import Profile was updated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/book/{bid}/recurring": {
+            "get": {
+                "description": "Get Recurring Transactions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "RecurringTransaction"
+                ],
+                "summary": "Get Recurring Transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recurring Transactions was found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create Recurring Transaction which is created by the scheduler on the dates of the rule, optionally as drafts",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "RecurringTransaction"
+                ],
+                "summary": "Create Recurring Transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create Recurring Transaction",
+                        "name": "recurringTransaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/endpoint.CreateRecurringTransactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recurring Transaction was created",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed or Recurring Transaction is invalid",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/book/{bid}/recurring/{rid}": {
+            "get": {
+                "description": "Get Recurring Transaction with the upcoming occurrences",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "RecurringTransaction"
+                ],
+                "summary": "Get Recurring Transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recurring Transaction ID",
+                        "name": "rid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recurring Transaction was found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete Recurring Transaction. The transactions already created are not deleted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "RecurringTransaction"
+                ],
+                "summary": "Delete Recurring Transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recurring Transaction ID",
+                        "name": "rid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recurring Transaction was deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update Recurring Transaction. The transactions already created are not changed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "RecurringTransaction"
+                ],
+                "summary": "Update Recurring Transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recurring Transaction ID",
+                        "name": "rid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update Recurring Transaction",
+                        "name": "recurringTransaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/endpoint.UpdateRecurringTransactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recurring Transaction was updated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed or Recurring Transaction is invalid",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/book/{bid}/report/balanceSheet": {
+            "get": {
+                "description": "Get Balance Sheet (貸借対照表) at the end of the period",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Get Balance Sheet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "To (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Balance Sheet was generated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/book/{bid}/report/profitAndLoss": {
+            "get": {
+                "description": "Get Profit and Loss Statement (損益計算書) of the period",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Get Profit and Loss",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "From (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Profit and Loss was generated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/book/{bid}/report/trialBalance": {
+            "get": {
+                "description": "Get Trial Balance of the period",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Get Trial Balance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "From (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trial Balance was generated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/book/{bid}/rollover": {
+            "post": {
+                "description": "Close the book and create the book of the next year carrying forward balance sheet accounts",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Book"
+                ],
+                "summary": "Rollover Book",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rollover Book",
+                        "name": "book",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/endpoint.RolloverBookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Book was rolled over",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Book was already rolled over",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/book/{bid}/rule": {
+            "get": {
+                "description": "Get Rules in the order of the priority",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rule"
+                ],
+                "summary": "Get Rules",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rules was found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create Rule which completes the counter account of imported or created transactions with only one line",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rule"
+                ],
+                "summary": "Create Rule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create Rule",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/endpoint.CreateRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rule was created",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed or Rule is invalid",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/book/{bid}/rule/dryRun": {
+            "get": {
+                "description": "Find existing transactions with only one line which the rules would complete without changing them. Each transaction is reported with the first matching rule.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rule"
+                ],
+                "summary": "Dry Run Rules",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Rule ID (all enabled rules by default)",
+                        "name": "rule_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit of matches (default 100, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Account Title IDs",
+                        "name": "account_title_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum amount of a sub transaction",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum amount of a sub transaction",
+                        "name": "max_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "debit or credit",
+                        "name": "side",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Substring of the description",
+                        "name": "description",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rules was dry run",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/book/{bid}/rule/{rid}": {
+            "delete": {
+                "description": "Delete Rule",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rule"
+                ],
+                "summary": "Delete Rule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Rule ID",
+                        "name": "rid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rule was deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update Rule",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rule"
+                ],
+                "summary": "Update Rule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Rule ID",
+                        "name": "rid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update Rule",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/endpoint.UpdateRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rule was updated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed or Rule is invalid",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/book/{bid}/transaction": {
+            "get": {
+                "description": "Get Transactions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Get Transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "From (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Account Title IDs",
+                        "name": "account_title_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum amount of a sub transaction",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum amount of a sub transaction",
+                        "name": "max_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "debit or credit",
+                        "name": "side",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Substring of the description",
+                        "name": "description",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "posted or draft",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transactions was found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create Transaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Create Transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create Transaction",
+                        "name": "transaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/endpoint.CreateTransactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Created Transaction",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed or Transaction is invalid",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/book/{bid}/transaction/batch": {
+            "post": {
+                "description": "Create up to 1000 Transactions at once. All the transactions are created or none of them, and the errors are reported per transaction.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Create Transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create Transactions",
+                        "name": "transactions",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/endpoint.CreateTransactionsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transactions was created",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed or Transactions are invalid",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/book/{bid}/transaction/page/{pid}": {
+            "get": {
+                "description": "Get Transactions with Page",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Get Transactions with Page",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Page ID",
+                        "name": "pid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "From (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Account Title IDs",
+                        "name": "account_title_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum amount of a sub transaction",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum amount of a sub transaction",
+                        "name": "max_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "debit or credit",
+                        "name": "side",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Substring of the description",
+                        "name": "description",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "posted or draft",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transactions was found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/book/{bid}/transaction/post": {
+            "post": {
+                "description": "Post draft Transactions to the balances. A draft with only one line is completed by the rules. All the transactions are posted or none of them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Post Transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Post Transactions",
+                        "name": "transactions",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/endpoint.PostTransactionsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transactions was posted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed or Transactions are invalid",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/book/{bid}/transaction/{tid}": {
+            "get": {
+                "description": "Get Transaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Get Transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "tid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transaction was found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete Transaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Delete Transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "tid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transaction was deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update Transaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Update Transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book ID",
+                        "name": "bid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "tid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update Transaction",
+                        "name": "transaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/endpoint.UpdateTransactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated Transaction",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed or Transaction is invalid",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/bookTemplate": {
+            "get": {
+                "description": "Get templates of account titles for a new book",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Book"
+                ],
+                "summary": "Get Book Templates",
+                "responses": {
+                    "200": {
+                        "description": "Book Templates was found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "Login",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Login",
+                "parameters": [
+                    {
+                        "description": "Login",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/endpoint.LoginWithEmailAndPassword"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Login",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/logout": {
+            "get": {
+                "description": "Logout and revoke the token of the current session",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Logout",
+                "responses": {
+                    "200": {
+                        "description": "Logout",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/password/forgot": {
+            "post": {
+                "description": "Send the mail to reset the password. The response is the same whether the email is registered or not.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Forgot Password",
+                "parameters": [
+                    {
+                        "description": "Forgot Password",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/endpoint.ForgotPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password Reset Requested",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/password/reset": {
+            "post": {
+                "description": "Reset the password with the token of the mail. The token can be used only once, and all the sessions of the user are logged out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Reset Password",
+                "parameters": [
+                    {
+                        "description": "Reset Password",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/endpoint.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password was reset",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed or Token is invalid",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/ping": {
+            "get": {
+                "description": "Ping",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ping"
+                ],
+                "summary": "Ping",
+                "responses": {
+                    "200": {
+                        "description": "pong",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/session": {
+            "get": {
+                "description": "Get the logged in sessions of the User. current_session_id is the session of the request.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Get Sessions",
+                "responses": {
+                    "200": {
+                        "description": "Sessions was found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Log out everywhere including the current session",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Delete All Sessions",
+                "responses": {
+                    "200": {
+                        "description": "Sessions was deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/session/{sid}": {
+            "delete": {
+                "description": "Log out the session and revoke its token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Delete Session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "sid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session was deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/token/refresh": {
+            "post": {
+                "description": "Issue a new access token with the refresh token cookie. The refresh token is rotated, and reusing an old one logs out the session. The requests refreshing with the same token within a few seconds get the same new token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Refresh Token",
+                "responses": {
+                    "200": {
+                        "description": "Token was refreshed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "get": {
+                "description": "Get User",
                 "consumes": [
                     "application/json"
                 ],
@@ -947,38 +2847,328 @@ const docTemplate = `{
                         }
                     }
                 }
+            },
+            "patch": {
+                "description": "Update the name of the User. The email and the password are changed by their own endpoints.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Update User",
+                "parameters": [
+                    {
+                        "description": "Update User",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/endpoint.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User was updated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/email": {
+            "post": {
+                "description": "Send the confirmation mail to the new email. The email is changed when the link of the mail is opened.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Change Email",
+                "parameters": [
+                    {
+                        "description": "Change Email",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/endpoint.ChangeEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Email Change Requested",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Password was not currect",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/password": {
+            "post": {
+                "description": "Change the password with the current password. The other sessions are logged out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Change Password",
+                "parameters": [
+                    {
+                        "description": "Change Password",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/endpoint.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password was changed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Request is failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Password was not currect",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "endpoint.ChangeEmailRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "endpoint.ChangePasswordRequest": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "endpoint.CreateAccountTitleRequest": {
+            "type": "object",
+            "required": [
+                "category",
+                "name"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "amount_base": {
+                    "type": "integer"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "closes_at_year_end": {
+                    "type": "boolean"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "is_contra": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "sort_order": {
+                    "type": "integer"
+                }
+            }
+        },
+        "endpoint.CreateBookRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "year"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "template": {
+                    "type": "string"
+                },
+                "year": {
+                    "type": "integer"
+                }
             }
-        }
-    },
-    "definitions": {
-        "endpoint.CreateAccountTitleRequest": {
+        },
+        "endpoint.CreateImportProfileRequest": {
             "type": "object",
             "required": [
+                "date_column",
+                "description_column",
                 "name"
             ],
             "properties": {
-                "amount": {
+                "amount_column": {
+                    "type": "integer"
+                },
+                "date_column": {
+                    "type": "integer"
+                },
+                "date_format": {
+                    "type": "string"
+                },
+                "deposit_column": {
+                    "type": "integer"
+                },
+                "description_column": {
                     "type": "integer"
                 },
+                "encoding": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
-                "type": {
+                "skip_rows": {
+                    "type": "integer"
+                },
+                "withdrawal_column": {
                     "type": "integer"
                 }
             }
         },
-        "endpoint.CreateBookRequest": {
+        "endpoint.CreateRecurringTransactionRequest": {
             "type": "object",
             "required": [
-                "name",
-                "year"
+                "description",
+                "rule",
+                "starts_at",
+                "sub_transactions"
+            ],
+            "properties": {
+                "as_draft": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "is_enabled": {
+                    "type": "boolean"
+                },
+                "rule": {
+                    "type": "string"
+                },
+                "starts_at": {
+                    "type": "string"
+                },
+                "sub_transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.RecurringSubTransaction"
+                    }
+                }
+            }
+        },
+        "endpoint.CreateRuleRequest": {
+            "type": "object",
+            "required": [
+                "counter_account_title_id",
+                "name"
             ],
             "properties": {
+                "counter_account_title_id": {
+                    "type": "integer"
+                },
+                "description_contains": {
+                    "type": "string"
+                },
+                "description_pattern": {
+                    "type": "string"
+                },
+                "description_rewrite": {
+                    "type": "string"
+                },
+                "is_enabled": {
+                    "type": "boolean"
+                },
+                "max_amount": {
+                    "type": "integer"
+                },
+                "min_amount": {
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
                 },
-                "year": {
+                "priority": {
+                    "type": "integer"
+                },
+                "source_account_title_id": {
                     "type": "integer"
                 }
             }
@@ -987,16 +3177,19 @@ const docTemplate = `{
             "type": "object",
             "required": [
                 "description",
-                "occured_at",
+                "occurred_at",
                 "sub_transactions"
             ],
             "properties": {
                 "description": {
                     "type": "string"
                 },
-                "occured_at": {
+                "occurred_at": {
                     "type": "string"
                 },
+                "status": {
+                    "$ref": "#/definitions/model.TransactionStatus"
+                },
                 "sub_transactions": {
                     "type": "array",
                     "items": {
@@ -1005,6 +3198,20 @@ const docTemplate = `{
                 }
             }
         },
+        "endpoint.CreateTransactionsRequest": {
+            "type": "object",
+            "required": [
+                "transactions"
+            ],
+            "properties": {
+                "transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/endpoint.CreateTransactionRequest"
+                    }
+                }
+            }
+        },
         "endpoint.CreateUserRequest": {
             "type": "object",
             "required": [
@@ -1024,6 +3231,17 @@ const docTemplate = `{
                 }
             }
         },
+        "endpoint.ForgotPasswordRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
         "endpoint.LoginWithEmailAndPassword": {
             "type": "object",
             "required": [
@@ -1031,6 +3249,9 @@ const docTemplate = `{
                 "password"
             ],
             "properties": {
+                "device": {
+                    "type": "string"
+                },
                 "email": {
                     "type": "string"
                 },
@@ -1039,16 +3260,77 @@ const docTemplate = `{
                 }
             }
         },
+        "endpoint.PostTransactionsRequest": {
+            "type": "object",
+            "required": [
+                "transaction_ids"
+            ],
+            "properties": {
+                "transaction_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "endpoint.ResetPasswordRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "endpoint.RolloverBookRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "retained_earnings_title_id": {
+                    "type": "integer"
+                },
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
         "endpoint.UpdateAccountTitleRequest": {
             "type": "object",
             "properties": {
                 "amount": {
                     "type": "integer"
                 },
+                "amount_base": {
+                    "type": "integer"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "closes_at_year_end": {
+                    "type": "boolean"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "is_contra": {
+                    "type": "boolean"
+                },
                 "name": {
                     "type": "string"
                 },
-                "type": {
+                "parent_id": {
+                    "type": "integer"
+                },
+                "sort_order": {
                     "type": "integer"
                 }
             }
@@ -1064,13 +3346,106 @@ const docTemplate = `{
                 }
             }
         },
+        "endpoint.UpdateImportProfileRequest": {
+            "type": "object",
+            "properties": {
+                "amount_column": {
+                    "type": "integer"
+                },
+                "date_column": {
+                    "type": "integer"
+                },
+                "date_format": {
+                    "type": "string"
+                },
+                "deposit_column": {
+                    "type": "integer"
+                },
+                "description_column": {
+                    "type": "integer"
+                },
+                "encoding": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "skip_rows": {
+                    "type": "integer"
+                },
+                "withdrawal_column": {
+                    "type": "integer"
+                }
+            }
+        },
+        "endpoint.UpdateRecurringTransactionRequest": {
+            "type": "object",
+            "properties": {
+                "as_draft": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "is_enabled": {
+                    "type": "boolean"
+                },
+                "rule": {
+                    "type": "string"
+                },
+                "starts_at": {
+                    "type": "string"
+                },
+                "sub_transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.RecurringSubTransaction"
+                    }
+                }
+            }
+        },
+        "endpoint.UpdateRuleRequest": {
+            "type": "object",
+            "properties": {
+                "counter_account_title_id": {
+                    "type": "integer"
+                },
+                "description_contains": {
+                    "type": "string"
+                },
+                "description_pattern": {
+                    "type": "string"
+                },
+                "description_rewrite": {
+                    "type": "string"
+                },
+                "is_enabled": {
+                    "type": "boolean"
+                },
+                "max_amount": {
+                    "type": "integer"
+                },
+                "min_amount": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "source_account_title_id": {
+                    "type": "integer"
+                }
+            }
+        },
         "endpoint.UpdateTransactionRequest": {
             "type": "object",
             "properties": {
                 "description": {
                     "type": "string"
                 },
-                "occured_at": {
+                "occurred_at": {
                     "type": "string"
                 },
                 "sub_transactions": {
@@ -1081,6 +3456,38 @@ const docTemplate = `{
                 }
             }
         },
+        "endpoint.UpdateUserRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AccountCategory": {
+            "type": "string",
+            "enum": [
+                "asset",
+                "liability",
+                "equity",
+                "revenue",
+                "expense"
+            ],
+            "x-enum-comments": {
+                "AccountCategoryAsset": "資産",
+                "AccountCategoryEquity": "純資産",
+                "AccountCategoryExpense": "費用",
+                "AccountCategoryLiability": "負債",
+                "AccountCategoryRevenue": "収益"
+            },
+            "x-enum-varnames": [
+                "AccountCategoryAsset",
+                "AccountCategoryLiability",
+                "AccountCategoryEquity",
+                "AccountCategoryRevenue",
+                "AccountCategoryExpense"
+            ]
+        },
         "model.AccountTitle": {
             "type": "object",
             "properties": {
@@ -1093,12 +3500,33 @@ const docTemplate = `{
                 "book_id": {
                     "type": "string"
                 },
+                "category": {
+                    "$ref": "#/definitions/model.AccountCategory"
+                },
+                "closes_at_year_end": {
+                    "type": "boolean"
+                },
+                "code": {
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "is_contra": {
+                    "type": "boolean"
+                },
                 "name": {
                     "type": "string"
                 },
+                "normal_balance": {
+                    "$ref": "#/definitions/model.BalanceSide"
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "sort_order": {
+                    "type": "integer"
+                },
                 "sub_transactions": {
                     "type": "array",
                     "items": {
@@ -1108,7 +3536,38 @@ const docTemplate = `{
                 "title_id": {
                     "type": "integer"
                 },
-                "type": {
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.BalanceSide": {
+            "type": "string",
+            "enum": [
+                "debit",
+                "credit"
+            ],
+            "x-enum-varnames": [
+                "BalanceSideDebit",
+                "BalanceSideCredit"
+            ]
+        },
+        "model.RecurringSubTransaction": {
+            "type": "object",
+            "properties": {
+                "account_title_id": {
+                    "type": "integer"
+                },
+                "amount": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "is_debit": {
+                    "type": "boolean"
+                },
+                "recurring_sub_transaction_id": {
                     "type": "integer"
                 },
                 "updated_at": {
@@ -1157,9 +3616,15 @@ const docTemplate = `{
                 "description": {
                     "type": "string"
                 },
+                "external_id": {
+                    "type": "string"
+                },
                 "occurred_at": {
                     "type": "string"
                 },
+                "status": {
+                    "$ref": "#/definitions/model.TransactionStatus"
+                },
                 "sub_transactions": {
                     "type": "array",
                     "items": {
@@ -1173,6 +3638,17 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "model.TransactionStatus": {
+            "type": "string",
+            "enum": [
+                "posted",
+                "draft"
+            ],
+            "x-enum-varnames": [
+                "TransactionStatusPosted",
+                "TransactionStatusDraft"
+            ]
         }
     }
 }`
@@ -1,14 +1,12 @@
 package endpoint
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
-	"strconv"
+	"time"
 
 	"github.com/Prokuma/PLAccounting-Backend/crud"
 	model "github.com/Prokuma/PLAccounting-Backend/models"
@@ -51,7 +49,6 @@ type CreateUserRequest struct {
 func CreateUser(c *gin.Context) {
 	var createUser CreateUserRequest
 	err := c.BindJSON(&createUser)
-	salt := os.Getenv("HASH_SALT")
 
 	if err != nil {
 		c.String(http.StatusBadRequest, "Request was failed ")
@@ -59,8 +56,12 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
-	r := sha256.Sum256([]byte(createUser.Password + salt))
-	hash := hex.EncodeToString(r[:])
+	hash, err := util.HashPassword(createUser.Password)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Hashing Password was failed")
+		c.Abort()
+		return
+	}
 
 	userInfo := model.User{
 		Email:    createUser.Email,
@@ -133,9 +134,11 @@ func CreateUserAtDatabase(c *gin.Context) {
 	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte("Created User"))
 }
 
+// Device is the name of the device shown in the sessions (e.g. "MacBook")
 type LoginWithEmailAndPassword struct {
 	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	Device   string `json:"device"`
 }
 
 // Login godoc
@@ -151,7 +154,6 @@ type LoginWithEmailAndPassword struct {
 func Login(c *gin.Context) {
 	var loginInfo LoginWithEmailAndPassword
 	err := c.BindJSON(&loginInfo)
-	salt := os.Getenv("HASH_SALT")
 
 	if err != nil {
 		c.String(http.StatusBadRequest, "Request was failed "+err.Error())
@@ -159,9 +161,6 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	r := sha256.Sum256([]byte(loginInfo.Password + salt))
-	hash := hex.EncodeToString(r[:])
-
 	user, err := crud.GetUserFromEmail(loginInfo.Email)
 
 	if err != nil {
@@ -170,27 +169,106 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	if user.Password != hash {
+	ok, needsRehash, err := util.VerifyPassword(loginInfo.Password, user.Password)
+	if err != nil || !ok {
 		c.String(http.StatusForbidden, "Password was not currect")
 		c.Abort()
 		return
 	}
 
-	token, tokenLifeTime, err := util.GenerateToken(user.UserId)
+	// Replaces the hash of the older format while the password is known
+	if needsRehash {
+		hash, err := util.HashPassword(loginInfo.Password)
+		if err == nil {
+			err = crud.UpdateUserPassword(&user, hash)
+		}
+		if err != nil {
+			fmt.Println("Password could not rehashed: ", err)
+		}
+	}
+
+	session := util.NewSession(user.UserId, loginInfo.Device, c.ClientIP(), c.Request.UserAgent())
+	refreshToken, err := util.StartSession(&session)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Making Token was failed")
+		c.Abort()
+		return
+	}
+
+	token, tokenLifeTime, err := util.GenerateToken(&session)
+
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Making Token was failed")
+		c.Abort()
+		return
+	}
+
+	setTokenCookies(c, token, tokenLifeTime, refreshToken, &session)
+	c.JSON(http.StatusOK, gin.H{
+		"status":     200,
+		"email":      user.Email,
+		"name":       user.Name,
+		"expire":     tokenLifeTime,
+		"session_id": session.SessionId,
+		"message":    fmt.Sprintf("Succesed Login: %s", user.Name),
+	})
+}
+
+// Path of the refresh token cookie, which is sent only to RefreshToken
+const refreshTokenCookiePath = "/api/v1/token"
+
+func setTokenCookies(c *gin.Context, token string, tokenLifeTime int, refreshToken string, session *util.Session) {
+	c.SetCookie("token", token, tokenLifeTime, "/", os.Getenv("HOST"), false, true)
+	c.SetCookie("refresh_token", refreshToken, int(time.Until(session.ExpiresAt).Seconds()), refreshTokenCookiePath, os.Getenv("HOST"), false, true)
+}
+
+func clearTokenCookies(c *gin.Context) {
+	c.SetCookie("token", "", -1, "/", os.Getenv("HOST"), false, true)
+	c.SetCookie("refresh_token", "", -1, refreshTokenCookiePath, os.Getenv("HOST"), false, true)
+}
+
+// RefreshToken godoc
+// @Summary Refresh Token
+// @Tags User
+// @Description Issue a new access token with the refresh token cookie. The refresh token is rotated, and reusing an old one logs out the session. The requests refreshing with the same token within a few seconds get the same new token.
+// @Accept  json
+// @Produce  json
+// @Success 200 {string} string	"Token was refreshed"
+// @Failure 401 {string} string	"Unauthorized"
+// @Router /token/refresh [post]
+func RefreshToken(c *gin.Context) {
+	refreshToken, err := c.Cookie("refresh_token")
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Unauthorized")
+		c.Abort()
+		return
+	}
+
+	session, newRefreshToken, err := util.RotateRefreshToken(refreshToken)
+	if err == util.InvalidSessionError || err == util.RefreshTokenReusedError {
+		clearTokenCookies(c)
+		c.String(http.StatusUnauthorized, "Unauthorized")
+		c.Abort()
+		return
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Refreshing Token was failed")
+		c.Abort()
+		return
+	}
 
+	token, tokenLifeTime, err := util.GenerateToken(&session)
 	if err != nil {
 		c.String(http.StatusInternalServerError, "Making Token was failed")
 		c.Abort()
 		return
 	}
 
-	c.SetCookie("token", token, tokenLifeTime*3600, "/", os.Getenv("HOST"), false, true)
+	setTokenCookies(c, token, tokenLifeTime, newRefreshToken, &session)
 	c.JSON(http.StatusOK, gin.H{
 		"status":  200,
-		"email":   user.Email,
-		"name":    user.Name,
-		"expire":  tokenLifeTime * 3600,
-		"message": fmt.Sprintf("Succesed Login: %s", user.Name),
+		"expire":  tokenLifeTime,
+		"message": "Token was refreshed",
 	})
 }
 
@@ -222,22 +300,27 @@ func GetUser(c *gin.Context) {
 // Logout godoc
 // @Summary Logout
 // @Tags User
-// @Description Logout
+// @Description Logout and revoke the token of the current session
 // @Accept  json
 // @Produce  json
 // @Success 200 {string} string	"Logout"
 // @Failure 400 {string} string	"Request is failed"
 // @Router /logout [get]
 func Logout(c *gin.Context) {
-	user, err := getUserIdFromJWT(c)
+	user, session, err := getSessionFromJWT(c)
 	if err != nil {
 		c.String(http.StatusUnauthorized, "Not Logged In")
 		c.Abort()
 		return
 	}
 
-	util.Redis.Del(util.Context, user.UserId)
-	c.SetCookie("token", "", 0, "/", os.Getenv("HOST"), false, true)
+	err = util.DeleteSession(user.UserId, session.SessionId)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Logout was failed")
+		c.Abort()
+		return
+	}
+	clearTokenCookies(c)
 	c.JSON(http.StatusOK, gin.H{
 		"status":  200,
 		"message": "Succesed Logout",
@@ -245,45 +328,54 @@ func Logout(c *gin.Context) {
 }
 
 func getUserIdFromJWT(c *gin.Context) (model.User, error) {
+	user, _, err := getSessionFromJWT(c)
+	return user, err
+}
+
+// User and session of the token, the token is valid while the session is not deleted
+func getSessionFromJWT(c *gin.Context) (model.User, util.Session, error) {
 	tokenString, err := c.Cookie("token")
 	if err != nil {
 		c.String(http.StatusUnauthorized, "Unauthorized")
-		return model.User{}, err
+		return model.User{}, util.Session{}, err
 	}
 
 	token, err := util.ParseToken(tokenString)
 	if err != nil {
 		c.String(http.StatusUnauthorized, "Unauthorized")
-		return model.User{}, err
+		return model.User{}, util.Session{}, err
 	}
 
 	claims := token.Claims.(*util.TokenClaims)
-	userId := claims.UserId
-	exp := claims.Exp
-
-	ret, err := util.Redis.Get(util.Context, tokenString).Result()
-	if err != nil {
-		fmt.Println("Error: ", err)
+	if claims.SessionId == "" || claims.Exp < time.Now().Unix() {
 		c.String(http.StatusUnauthorized, "Unauthorized")
-		return model.User{}, err
+		return model.User{}, util.Session{}, NoAuthorizationError
 	}
 
-	retInt, err := strconv.ParseInt(ret, 10, 64)
+	session, err := util.GetSession(claims.SessionId)
+	if err == util.InvalidSessionError {
+		c.String(http.StatusUnauthorized, "Unauthorized")
+		return model.User{}, util.Session{}, err
+	}
 	if err != nil {
-		fmt.Println("Error: ", err)
 		c.String(http.StatusInternalServerError, "Internal Server Error")
-		return model.User{}, err
+		return model.User{}, util.Session{}, err
 	}
-	if retInt < exp {
+	if session.UserId != claims.UserId {
 		c.String(http.StatusUnauthorized, "Unauthorized")
-		return model.User{}, NoAuthorizationError
+		return model.User{}, util.Session{}, NoAuthorizationError
 	}
 
-	user, err := crud.GetUser(userId)
+	user, err := crud.GetUser(claims.UserId)
 	if err != nil {
 		c.String(http.StatusUnauthorized, "Unauthorized")
-		return model.User{}, err
+		return model.User{}, util.Session{}, err
+	}
+
+	err = util.TouchSession(&session, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		fmt.Println("Session could not updated: ", err)
 	}
 
-	return user, nil
+	return user, session, nil
 }
@@ -11,9 +11,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// Template is the template_id of GET /bookTemplate, or empty for a book without account titles
 type CreateBookRequest struct {
-	Name string `json:"name" binding:"required"`
-	Year uint   `json:"year" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Year     uint   `json:"year" binding:"required"`
+	Template string `json:"template"`
 }
 
 // CreateBook godoc
@@ -46,7 +48,12 @@ func CreateBook(c *gin.Context) {
 		Year: createBook.Year,
 	}
 
-	err = crud.CreateBook(&user, &book)
+	err = crud.CreateBook(&user, &book, createBook.Template)
+	if err == crud.InvalidBookTemplateError {
+		c.String(http.StatusBadRequest, "Book Template is invalid")
+		c.Abort()
+		return
+	}
 	if err != nil {
 		c.String(http.StatusInternalServerError, "Book could not created")
 		c.Abort()
@@ -59,6 +66,21 @@ func CreateBook(c *gin.Context) {
 	})
 }
 
+// GetBookTemplates godoc
+// @Summary Get Book Templates
+// @Tags Book
+// @Description Get templates of account titles for a new book
+// @Accept  json
+// @Produce  json
+// @Success 200 {string} string	"Book Templates was found"
+// @Router /bookTemplate [get]
+func GetBookTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"book_templates": crud.GetBookTemplates(),
+		"message":        "Book Templates was found",
+	})
+}
+
 // GetBook godoc
 // @Summary Get Book
 // @Tags Book
@@ -111,6 +133,8 @@ func GetBook(c *gin.Context) {
 // @Description Get All Books
 // @Accept  json
 // @Produce  json
+// @Param limit query int false "Limit (all books by default, max 100)"
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {string} string	"Get All Books"
 // @Failure 400 {string} string	"Request is failed"
 // @Router /book [get]
@@ -122,16 +146,30 @@ func GetAllBooks(c *gin.Context) {
 		return
 	}
 
-	books, err := crud.GetAllBooks(&user)
+	pagination, err := getPagination(c, 0)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Limit is invalid")
+		c.Abort()
+		return
+	}
+
+	books, pageInfo, err := crud.GetAllBooks(&user, &pagination)
 	if err != nil {
+		if err == crud.InvalidCursorError {
+			c.String(http.StatusBadRequest, "Cursor is invalid")
+			c.Abort()
+			return
+		}
 		c.String(http.StatusNotFound, "No Book")
 		c.Abort()
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"books":   books,
-		"message": "Books was found",
+		"books":       books,
+		"total":       pageInfo.Total,
+		"next_cursor": pageInfo.NextCursor,
+		"message":     "Books was found",
 	})
 }
 
@@ -254,6 +292,92 @@ func DeleteBook(c *gin.Context) {
 	})
 }
 
+// Name and Year default to the name and the next year of the book.
+// RetainedEarningsTitleId defaults to the account title named 元入金 or 繰越利益剰余金.
+type RolloverBookRequest struct {
+	Name                    *string `json:"name"`
+	Year                    *uint   `json:"year"`
+	RetainedEarningsTitleId uint64  `json:"retained_earnings_title_id"`
+}
+
+// RolloverBook godoc
+// @Summary Rollover Book
+// @Tags Book
+// @Description Close the book and create the book of the next year carrying forward balance sheet accounts
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param book body RolloverBookRequest true "Rollover Book"
+// @Success 200 {string} string	"Book was rolled over"
+// @Failure 400 {string} string	"Request is failed"
+// @Failure 409 {string} string	"Book was already rolled over"
+// @Router /book/{bid}/rollover [post]
+func RolloverBook(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "admin") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	var rolloverBook RolloverBookRequest
+	err = c.BindJSON(&rolloverBook)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Infection Informations")
+		c.Abort()
+		return
+	}
+
+	name := book.Name
+	if rolloverBook.Name != nil {
+		name = *rolloverBook.Name
+	}
+	year := book.Year + 1
+	if rolloverBook.Year != nil {
+		year = *rolloverBook.Year
+	}
+
+	newBook, err := crud.CreateBookAndAccountTitleFromBook(year, name, &user, &book, rolloverBook.RetainedEarningsTitleId)
+	if err == crud.NoRetainedEarningsTitleError {
+		c.String(http.StatusBadRequest, "Retained Earnings Account Title was not found")
+		c.Abort()
+		return
+	}
+	if err == crud.AlreadyRolledOverError {
+		c.String(http.StatusConflict, "Book was already rolled over")
+		c.Abort()
+		return
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Book could not rolled over")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"book":    newBook,
+		"message": "Book was rolled over",
+	})
+}
+
 type CreateBookAuthorizationRequest struct {
 	UserId    string `json:"user_id" binding:"required"`
 	Authority string `json:"authority" binding:"required"`
@@ -429,11 +553,20 @@ func GetBookAuthorizations(c *gin.Context) {
 	})
 }
 
+// Category is one of asset, liability, equity, revenue and expense.
+// Contra accounts (e.g. 減価償却累計額) have the opposite normal balance of their category.
+// ClosesAtYearEnd closes the equity account into the retained earnings at year end (e.g. 事業主貸, 事業主借),
+// and it is true for contra equity accounts by default.
 type CreateAccountTitleRequest struct {
-	Name       string `json:"name" binding:"required"`
-	Amount     int64  `json:"amount"`
-	AmountBase int64  `json:"amount_base"`
-	Type       uint   `json:"type"`
+	Name            string  `json:"name" binding:"required"`
+	Amount          int64   `json:"amount"`
+	AmountBase      int64   `json:"amount_base"`
+	Category        string  `json:"category" binding:"required"`
+	IsContra        bool    `json:"is_contra"`
+	ParentId        *uint64 `json:"parent_id"`
+	Code            string  `json:"code"`
+	SortOrder       int     `json:"sort_order"`
+	ClosesAtYearEnd *bool   `json:"closes_at_year_end"`
 }
 
 // CreateAccountTitle godoc
@@ -488,10 +621,28 @@ func CreateAccountTitle(c *gin.Context) {
 		Name:       createAccountTitle.Name,
 		Amount:     createAccountTitle.Amount,
 		AmountBase: createAccountTitle.AmountBase,
-		Type:       createAccountTitle.Type,
+		Category:   model.AccountCategory(createAccountTitle.Category),
+		IsContra:   createAccountTitle.IsContra,
+		ParentId:   createAccountTitle.ParentId,
+		Code:       createAccountTitle.Code,
+		SortOrder:  createAccountTitle.SortOrder,
+	}
+	accountTitle.ClosesAtYearEnd = accountTitle.Category == model.AccountCategoryEquity && accountTitle.IsContra
+	if createAccountTitle.ClosesAtYearEnd != nil {
+		accountTitle.ClosesAtYearEnd = *createAccountTitle.ClosesAtYearEnd
 	}
 
 	err = crud.CreateAccountTitle(&accountTitle)
+	if err == crud.InvalidAccountCategoryError {
+		c.String(http.StatusBadRequest, "Account Category is invalid")
+		c.Abort()
+		return
+	}
+	if err == crud.InvalidParentAccountTitleError {
+		c.String(http.StatusBadRequest, "Parent Account Title is invalid")
+		c.Abort()
+		return
+	}
 	if err != nil {
 		c.String(http.StatusInternalServerError, "Create Account Title was failed")
 		c.Abort()
@@ -564,10 +715,12 @@ func GetAccountTitle(c *gin.Context) {
 // GetAllAccountTitles godoc
 // @Summary Get All Account Titles
 // @Tags Account Title
-// @Description Get All Account Titles
+// @Description Get All Account Titles ordered by sort order and code, with the tree of sub accounts
 // @Accept  json
 // @Produce  json
 // @Param bid path string true "Book ID"
+// @Param limit query int false "Limit of account_titles (all account titles by default, max 100)"
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {string} string	"Get All Account Titles"
 // @Failure 400 {string} string	"Request is failed"
 // @Router /book/{bid}/accountTitle [get]
@@ -597,7 +750,26 @@ func GetAllAccountTitles(c *gin.Context) {
 		return
 	}
 
-	accountTitles, err := crud.GetAllAccountTitles(&book)
+	pagination, err := getPagination(c, 0)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Limit is invalid")
+		c.Abort()
+		return
+	}
+
+	accountTitles, pageInfo, err := crud.GetAccountTitles(&book, &pagination)
+	if err != nil {
+		if err == crud.InvalidCursorError {
+			c.String(http.StatusBadRequest, "Cursor is invalid")
+			c.Abort()
+			return
+		}
+		c.String(http.StatusNotFound, "No Account Title")
+		c.Abort()
+		return
+	}
+
+	accountTitleTree, err := crud.GetAccountTitleTree(&book)
 	if err != nil {
 		c.String(http.StatusNotFound, "No Account Title")
 		c.Abort()
@@ -605,16 +777,24 @@ func GetAllAccountTitles(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"account_titles": accountTitles,
-		"message":        "Account Titles was found",
+		"account_titles":     accountTitles,
+		"account_title_tree": accountTitleTree,
+		"total":              pageInfo.Total,
+		"next_cursor":        pageInfo.NextCursor,
+		"message":            "Account Titles was found",
 	})
 }
 
 type UpdateAccountTitleRequest struct {
-	Name       *string `json:"name"`
-	Amount     *int64  `json:"amount"`
-	AmountBase *int64  `json:"amount_base"`
-	Type       *uint   `json:"type"`
+	Name            *string `json:"name"`
+	Amount          *int64  `json:"amount"`
+	AmountBase      *int64  `json:"amount_base"`
+	Category        *string `json:"category"`
+	IsContra        *bool   `json:"is_contra"`
+	ParentId        *uint64 `json:"parent_id"`
+	Code            *string `json:"code"`
+	SortOrder       *int    `json:"sort_order"`
+	ClosesAtYearEnd *bool   `json:"closes_at_year_end"`
 }
 
 // UpdateAccountTitle godoc
@@ -682,17 +862,50 @@ func UpdateAccountTitle(c *gin.Context) {
 	if updateAccountTitle.Name != nil {
 		accountTitle.Name = *updateAccountTitle.Name
 	}
-	if updateAccountTitle.Amount != nil {
-		accountTitle.Amount = *updateAccountTitle.Amount
-	}
 	if updateAccountTitle.AmountBase != nil {
 		accountTitle.AmountBase = *updateAccountTitle.AmountBase
 	}
-	if updateAccountTitle.Type != nil {
-		accountTitle.Type = *updateAccountTitle.Type
+	if updateAccountTitle.Category != nil {
+		accountTitle.Category = model.AccountCategory(*updateAccountTitle.Category)
+	}
+	if updateAccountTitle.IsContra != nil {
+		accountTitle.IsContra = *updateAccountTitle.IsContra
+	}
+	if updateAccountTitle.ParentId != nil {
+		// parent_id 0 moves the account title to the top level
+		if *updateAccountTitle.ParentId == 0 {
+			accountTitle.ParentId = nil
+		} else {
+			accountTitle.ParentId = updateAccountTitle.ParentId
+		}
+	}
+	if updateAccountTitle.Code != nil {
+		accountTitle.Code = *updateAccountTitle.Code
+	}
+	if updateAccountTitle.SortOrder != nil {
+		accountTitle.SortOrder = *updateAccountTitle.SortOrder
+	}
+	if updateAccountTitle.ClosesAtYearEnd != nil {
+		accountTitle.ClosesAtYearEnd = *updateAccountTitle.ClosesAtYearEnd
 	}
 
-	err = crud.UpdateAccountTitle(&accountTitle)
+	// amount overwrites the balance, e.g. to correct it by hand
+	err = crud.UpdateAccountTitle(&accountTitle, updateAccountTitle.Amount)
+	if err == crud.InvalidAccountCategoryError {
+		c.String(http.StatusBadRequest, "Account Category is invalid")
+		c.Abort()
+		return
+	}
+	if err == crud.InvalidParentAccountTitleError {
+		c.String(http.StatusBadRequest, "Parent Account Title is invalid")
+		c.Abort()
+		return
+	}
+	if err == crud.SubAccountTitleCategoryError {
+		c.String(http.StatusBadRequest, "Category of the Account Title with Sub Account Titles can not changed")
+		c.Abort()
+		return
+	}
 	if err != nil {
 		c.String(http.StatusNotFound, "The account title could not deleted")
 		c.Abort()
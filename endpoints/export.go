@@ -0,0 +1,247 @@
+package endpoint
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Prokuma/PLAccounting-Backend/crud"
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+	util "github.com/Prokuma/PLAccounting-Backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+var journalCSVHeader = []string{"日付", "取引ID", "摘要", "借方科目", "借方金額", "貸方科目", "貸方金額"}
+var ledgerCSVHeader = []string{"日付", "取引ID", "摘要", "借方科目", "借方金額", "貸方科目", "貸方金額", "残高"}
+
+func journalCSVRecord(row *model.JournalRow) []string {
+	record := []string{
+		row.OccurredAt.Format(dateLayout),
+		strconv.FormatUint(row.TransactionId, 10),
+		row.Description,
+		row.DebitTitle,
+		"",
+		row.CreditTitle,
+		"",
+	}
+	if row.DebitTitle != "" {
+		record[4] = strconv.FormatInt(row.DebitAmount, 10)
+	}
+	if row.CreditTitle != "" {
+		record[6] = strconv.FormatInt(row.CreditAmount, 10)
+	}
+	return record
+}
+
+// Returns the encoding query parameter, Shift_JIS by default
+func getCSVEncoding(c *gin.Context) (string, bool) {
+	encodingName := strings.ToLower(c.DefaultQuery("encoding", util.CSVEncodingShiftJIS))
+	return encodingName, util.IsValidCSVEncoding(encodingName)
+}
+
+func setCSVHeaders(c *gin.Context, filename string, encodingName string) {
+	charset := "Shift_JIS"
+	if encodingName == util.CSVEncodingUTF8 {
+		charset = "UTF-8"
+	}
+	c.Header("Content-Type", "text/csv; charset="+charset)
+	c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	c.Status(http.StatusOK)
+}
+
+// ExportJournal godoc
+// @Summary Export Journal
+// @Tags Export
+// @Description Export Journal (仕訳帳) of the period as CSV
+// @Accept  json
+// @Produce  text/csv
+// @Param bid path string true "Book ID"
+// @Param from query string false "From (YYYY-MM-DD)"
+// @Param to query string false "To (YYYY-MM-DD)"
+// @Param encoding query string false "shift_jis (default) or utf-8 (with BOM)"
+// @Success 200 {string} string	"Journal CSV"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/export/journal.csv [get]
+func ExportJournal(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "read") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	from, to, err := getDateRange(c, &book)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Date is invalid")
+		c.Abort()
+		return
+	}
+
+	encodingName, ok := getCSVEncoding(c)
+	if !ok {
+		c.String(http.StatusBadRequest, "Encoding is invalid")
+		c.Abort()
+		return
+	}
+
+	setCSVHeaders(c, "journal.csv", encodingName)
+	writer, err := util.NewCSVWriter(c.Writer, encodingName)
+	if err != nil {
+		fmt.Println("Journal could not exported: ", err)
+		return
+	}
+	defer func() {
+		// Writes the rest of the rows
+		if err := writer.Close(); err != nil {
+			fmt.Println("Journal could not exported: ", err)
+		}
+	}()
+
+	if err := writer.Write(journalCSVHeader); err != nil {
+		fmt.Println("Journal could not exported: ", err)
+		return
+	}
+	err = crud.ExportJournal(&book, from, to, func(rows []model.JournalRow) error {
+		for idx := range rows {
+			if err := writer.Write(journalCSVRecord(&rows[idx])); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		c.Writer.Flush()
+		return writer.Error()
+	})
+	if err != nil {
+		// The status was already sent, so the CSV is cut off here
+		fmt.Println("Journal could not exported: ", err)
+	}
+}
+
+// ExportLedger godoc
+// @Summary Export Ledger
+// @Tags Export
+// @Description Export General Ledger (総勘定元帳) of the account title as CSV with running balance
+// @Accept  json
+// @Produce  text/csv
+// @Param bid path string true "Book ID"
+// @Param tid path string true "Account Title ID"
+// @Param from query string false "From (YYYY-MM-DD)"
+// @Param to query string false "To (YYYY-MM-DD)"
+// @Param encoding query string false "shift_jis (default) or utf-8 (with BOM)"
+// @Success 200 {string} string	"Ledger CSV"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/accountTitle/{tid}/ledger.csv [get]
+func ExportLedger(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "read") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	accountTitleId, err := strconv.ParseUint(c.Param("tid"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Account Title ID is invalid")
+		c.Abort()
+		return
+	}
+
+	from, to, err := getDateRange(c, &book)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Date is invalid")
+		c.Abort()
+		return
+	}
+
+	encodingName, ok := getCSVEncoding(c)
+	if !ok {
+		c.String(http.StatusBadRequest, "Encoding is invalid")
+		c.Abort()
+		return
+	}
+
+	ledger, err := crud.GetLedgerOpening(&book, accountTitleId, from, to)
+	if err != nil {
+		c.String(http.StatusNotFound, "Ledger could not generated")
+		c.Abort()
+		return
+	}
+
+	setCSVHeaders(c, "ledger_"+strconv.FormatUint(accountTitleId, 10)+".csv", encodingName)
+	writer, err := util.NewCSVWriter(c.Writer, encodingName)
+	if err != nil {
+		fmt.Println("Ledger could not exported: ", err)
+		return
+	}
+	defer func() {
+		// Writes the rest of the rows
+		if err := writer.Close(); err != nil {
+			fmt.Println("Ledger could not exported: ", err)
+		}
+	}()
+
+	if err := writer.Write(ledgerCSVHeader); err != nil {
+		fmt.Println("Ledger could not exported: ", err)
+		return
+	}
+	err = writer.Write([]string{
+		from.Format(dateLayout), "", "前期繰越", "", "", "", "",
+		strconv.FormatInt(ledger.OpeningBalance, 10),
+	})
+	if err != nil {
+		fmt.Println("Ledger could not exported: ", err)
+		return
+	}
+	err = crud.ExportLedgerEntries(&book, &ledger, func(entries []model.LedgerEntry) error {
+		for idx, row := range crud.LedgerRowsOf(&ledger.AccountTitle, entries) {
+			record := append(journalCSVRecord(&row), strconv.FormatInt(entries[idx].Balance, 10))
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		c.Writer.Flush()
+		return writer.Error()
+	})
+	if err != nil {
+		// The status was already sent, so the CSV is cut off here
+		fmt.Println("Ledger could not exported: ", err)
+	}
+}
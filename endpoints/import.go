@@ -0,0 +1,566 @@
+package endpoint
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Prokuma/PLAccounting-Backend/crud"
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+	util "github.com/Prokuma/PLAccounting-Backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Columns are 0-based and -1 means the column is not in the CSV.
+// The amount is in the withdrawal and the deposit columns, or in AmountColumn with the sign (deposits are positive).
+// Encoding is shift_jis (default) or utf-8, and DateFormat is a Go layout like 2006/1/2 (default).
+type CreateImportProfileRequest struct {
+	Name              string `json:"name" binding:"required"`
+	Encoding          string `json:"encoding"`
+	SkipRows          int    `json:"skip_rows"`
+	DateColumn        *int   `json:"date_column" binding:"required"`
+	DateFormat        string `json:"date_format"`
+	DescriptionColumn *int   `json:"description_column" binding:"required"`
+	WithdrawalColumn  *int   `json:"withdrawal_column"`
+	DepositColumn     *int   `json:"deposit_column"`
+	AmountColumn      *int   `json:"amount_column"`
+}
+
+func isValidImportProfile(profile *model.ImportProfile) bool {
+	if !util.IsValidCSVEncoding(profile.Encoding) || profile.SkipRows < 0 {
+		return false
+	}
+	if profile.DateColumn < 0 || profile.DescriptionColumn < -1 {
+		return false
+	}
+	if profile.WithdrawalColumn < -1 || profile.DepositColumn < -1 || profile.AmountColumn < -1 {
+		return false
+	}
+	if profile.AmountColumn >= 0 {
+		return profile.WithdrawalColumn == -1 && profile.DepositColumn == -1
+	}
+	// The same column for both would net to zero, the signed amount is AmountColumn
+	if profile.WithdrawalColumn == profile.DepositColumn {
+		return false
+	}
+	return profile.WithdrawalColumn >= 0 || profile.DepositColumn >= 0
+}
+
+// -1 if the column is not in the request
+func columnOf(column *int) int {
+	if column == nil {
+		return -1
+	}
+	return *column
+}
+
+// CreateImportProfile godoc
+// @Summary Create Import Profile
+// @Tags Import
+// @Description Create Column Mapping Profile of Statement CSV
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param importProfile body CreateImportProfileRequest true "Create Import Profile"
+// @Success 200 {string} string	"Import Profile was created"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/importProfile [post]
+func CreateImportProfile(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "admin") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	var createImportProfile CreateImportProfileRequest
+	err = c.BindJSON(&createImportProfile)
+
+	if err != nil {
+		c.String(http.StatusBadRequest, "Infection Informations")
+		c.Abort()
+		return
+	}
+
+	profile := model.ImportProfile{
+		BookId:            book.BookId,
+		Name:              createImportProfile.Name,
+		Encoding:          strings.ToLower(createImportProfile.Encoding),
+		SkipRows:          createImportProfile.SkipRows,
+		DateColumn:        *createImportProfile.DateColumn,
+		DateFormat:        createImportProfile.DateFormat,
+		DescriptionColumn: *createImportProfile.DescriptionColumn,
+		WithdrawalColumn:  columnOf(createImportProfile.WithdrawalColumn),
+		DepositColumn:     columnOf(createImportProfile.DepositColumn),
+		AmountColumn:      columnOf(createImportProfile.AmountColumn),
+	}
+	if profile.Encoding == "" {
+		profile.Encoding = util.CSVEncodingShiftJIS
+	}
+	if !isValidImportProfile(&profile) {
+		c.String(http.StatusBadRequest, "Import Profile is invalid")
+		c.Abort()
+		return
+	}
+
+	err = crud.CreateImportProfile(&profile)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Import Profile could not created")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"import_profile": profile,
+		"message":        "Import Profile was created",
+	})
+}
+
+// GetImportProfiles godoc
+// @Summary Get Import Profiles
+// @Tags Import
+// @Description Get Column Mapping Profiles of Statement CSV
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Success 200 {string} string	"Import Profiles was found"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/importProfile [get]
+func GetImportProfiles(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "read") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	profiles, err := crud.GetImportProfiles(&book)
+	if err != nil {
+		c.String(http.StatusNotFound, "Import Profiles could not found")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"import_profiles": profiles,
+		"message":         "Import Profiles was found",
+	})
+}
+
+type UpdateImportProfileRequest struct {
+	Name              *string `json:"name"`
+	Encoding          *string `json:"encoding"`
+	SkipRows          *int    `json:"skip_rows"`
+	DateColumn        *int    `json:"date_column"`
+	DateFormat        *string `json:"date_format"`
+	DescriptionColumn *int    `json:"description_column"`
+	WithdrawalColumn  *int    `json:"withdrawal_column"`
+	DepositColumn     *int    `json:"deposit_column"`
+	AmountColumn      *int    `json:"amount_column"`
+}
+
+// UpdateImportProfile godoc
+// @Summary Update Import Profile
+// @Tags Import
+// @Description Update Column Mapping Profile of Statement CSV
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param pid path string true "Import Profile ID"
+// @Param importProfile body UpdateImportProfileRequest true "Update Import Profile"
+// @Success 200 {string} string	"Import Profile was updated"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/importProfile/{pid} [patch]
+func UpdateImportProfile(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "update") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	importProfileId, err := strconv.ParseUint(c.Param("pid"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Import Profile ID is invalid")
+		c.Abort()
+		return
+	}
+
+	profile, err := crud.GetImportProfile(&book, importProfileId)
+	if err != nil {
+		c.String(http.StatusNotFound, "Import Profile was not found")
+		c.Abort()
+		return
+	}
+
+	var updateImportProfile UpdateImportProfileRequest
+	err = c.BindJSON(&updateImportProfile)
+
+	if err != nil {
+		c.String(http.StatusBadRequest, "Infection Informations")
+		c.Abort()
+		return
+	}
+
+	if updateImportProfile.Name != nil {
+		profile.Name = *updateImportProfile.Name
+	}
+	if updateImportProfile.Encoding != nil {
+		profile.Encoding = strings.ToLower(*updateImportProfile.Encoding)
+	}
+	if updateImportProfile.SkipRows != nil {
+		profile.SkipRows = *updateImportProfile.SkipRows
+	}
+	if updateImportProfile.DateColumn != nil {
+		profile.DateColumn = *updateImportProfile.DateColumn
+	}
+	if updateImportProfile.DateFormat != nil {
+		profile.DateFormat = *updateImportProfile.DateFormat
+	}
+	if updateImportProfile.DescriptionColumn != nil {
+		profile.DescriptionColumn = *updateImportProfile.DescriptionColumn
+	}
+	if updateImportProfile.WithdrawalColumn != nil {
+		profile.WithdrawalColumn = *updateImportProfile.WithdrawalColumn
+	}
+	if updateImportProfile.DepositColumn != nil {
+		profile.DepositColumn = *updateImportProfile.DepositColumn
+	}
+	if updateImportProfile.AmountColumn != nil {
+		profile.AmountColumn = *updateImportProfile.AmountColumn
+	}
+	if !isValidImportProfile(&profile) {
+		c.String(http.StatusBadRequest, "Import Profile is invalid")
+		c.Abort()
+		return
+	}
+
+	err = crud.UpdateImportProfile(&profile)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Import Profile could not updated")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"import_profile": profile,
+		"message":        "Import Profile was updated",
+	})
+}
+
+// DeleteImportProfile godoc
+// @Summary Delete Import Profile
+// @Tags Import
+// @Description Delete Column Mapping Profile of Statement CSV
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param pid path string true "Import Profile ID"
+// @Success 200 {string} string	"Import Profile was deleted"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/importProfile/{pid} [delete]
+func DeleteImportProfile(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "admin") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	importProfileId, err := strconv.ParseUint(c.Param("pid"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Import Profile ID is invalid")
+		c.Abort()
+		return
+	}
+
+	err = crud.DeleteImportProfile(&book, importProfileId)
+	if err != nil {
+		c.String(http.StatusNotFound, "Import Profile could not delete")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Import Profile was deleted",
+	})
+}
+
+// Form of the statement import
+type statementImport struct {
+	book                  model.Book
+	accountTitleId        uint64
+	counterAccountTitleId uint64
+	file                  multipart.File
+}
+
+// Checks the authorization and reads the form of the statement import.
+// The file must be closed if ok.
+func bindStatementImport(c *gin.Context) (statementImport, bool) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return statementImport{}, false
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return statementImport{}, false
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return statementImport{}, false
+	}
+	if strings.Index(bookAuthorization.Authority, "write") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return statementImport{}, false
+	}
+
+	accountTitleId, err := strconv.ParseUint(c.PostForm("account_title_id"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Account Title ID is invalid")
+		c.Abort()
+		return statementImport{}, false
+	}
+	var counterAccountTitleId uint64
+	if counterAccountTitleIdForm := c.PostForm("counter_account_title_id"); counterAccountTitleIdForm != "" {
+		counterAccountTitleId, err = strconv.ParseUint(counterAccountTitleIdForm, 10, 64)
+		if err != nil {
+			c.String(http.StatusBadRequest, "Counter Account Title ID is invalid")
+			c.Abort()
+			return statementImport{}, false
+		}
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.String(http.StatusBadRequest, "File was not found")
+		c.Abort()
+		return statementImport{}, false
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.String(http.StatusBadRequest, "File could not opened")
+		c.Abort()
+		return statementImport{}, false
+	}
+
+	return statementImport{
+		book:                  book,
+		accountTitleId:        accountTitleId,
+		counterAccountTitleId: counterAccountTitleId,
+		file:                  file,
+	}, true
+}
+
+// Imports the lines parsed from the statement, or responds the parse error
+func importStatementLines(c *gin.Context, form *statementImport, lines []model.StatementLine, err error) {
+	if err != nil {
+		var parseError *util.StatementParseError
+		if errors.As(err, &parseError) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"row":     parseError.Row,
+				"message": "Statement is invalid: " + parseError.Message,
+			})
+			c.Abort()
+			return
+		}
+		c.String(http.StatusBadRequest, "Statement is invalid")
+		c.Abort()
+		return
+	}
+
+	transactions, skipped, err := crud.ImportStatement(&form.book, form.accountTitleId, form.counterAccountTitleId, lines)
+	if err != nil {
+		var importError *crud.StatementImportError
+		var validationError *crud.TransactionValidationError
+		if errors.As(err, &importError) && errors.As(err, &validationError) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"row":     importError.Row,
+				"errors":  validationError.Errors,
+				"message": "Transaction is invalid",
+			})
+			c.Abort()
+			return
+		}
+		c.String(http.StatusInternalServerError, "Statement could not imported")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": transactions,
+		"count":        len(transactions),
+		"skipped":      skipped,
+		"message":      "Statement was imported",
+	})
+}
+
+// ImportStatementCSV godoc
+// @Summary Import Statement CSV
+// @Tags Import
+// @Description Import bank or credit card statement CSV with the import profile as draft transactions. Deposits are debited to the account title and withdrawals are credited to it. The counter accounts are decided by the rules. Lines imported before are skipped.
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param file formData file true "Statement CSV"
+// @Param import_profile_id formData int true "Import Profile ID"
+// @Param account_title_id formData int true "Account Title ID of the bank or the credit card"
+// @Param counter_account_title_id formData int false "Account Title ID of the counter account when no rule matches"
+// @Success 200 {string} string	"Statement was imported"
+// @Failure 400 {string} string	"Request is failed or Statement is invalid"
+// @Router /book/{bid}/import/csv [post]
+func ImportStatementCSV(c *gin.Context) {
+	form, ok := bindStatementImport(c)
+	if !ok {
+		return
+	}
+	defer form.file.Close()
+
+	importProfileId, err := strconv.ParseUint(c.PostForm("import_profile_id"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Import Profile ID is invalid")
+		c.Abort()
+		return
+	}
+
+	profile, err := crud.GetImportProfile(&form.book, importProfileId)
+	if err != nil {
+		c.String(http.StatusNotFound, "Import Profile was not found")
+		c.Abort()
+		return
+	}
+
+	lines, err := util.ParseStatementCSV(form.file, &profile)
+	importStatementLines(c, &form, lines, err)
+}
+
+// ImportOFX godoc
+// @Summary Import OFX
+// @Tags Import
+// @Description Import OFX statement as draft transactions. Deposits are debited to the account title and withdrawals are credited to it. The counter accounts are decided by the rules. Lines imported before are skipped by FITID.
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param file formData file true "OFX"
+// @Param encoding formData string false "utf-8 (default) or shift_jis"
+// @Param account_title_id formData int true "Account Title ID of the bank or the credit card"
+// @Param counter_account_title_id formData int false "Account Title ID of the counter account when no rule matches"
+// @Success 200 {string} string	"Statement was imported"
+// @Failure 400 {string} string	"Request is failed or Statement is invalid"
+// @Router /book/{bid}/import/ofx [post]
+func ImportOFX(c *gin.Context) {
+	form, ok := bindStatementImport(c)
+	if !ok {
+		return
+	}
+	defer form.file.Close()
+
+	lines, err := util.ParseOFX(form.file, strings.ToLower(c.DefaultPostForm("encoding", util.CSVEncodingUTF8)))
+	importStatementLines(c, &form, lines, err)
+}
+
+// ImportQIF godoc
+// @Summary Import QIF
+// @Tags Import
+// @Description Import QIF statement as draft transactions. Deposits are debited to the account title and withdrawals are credited to it. The counter accounts are decided by the rules. Lines imported before are skipped by the date, the amount and the description.
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param file formData file true "QIF"
+// @Param encoding formData string false "utf-8 (default) or shift_jis"
+// @Param account_title_id formData int true "Account Title ID of the bank or the credit card"
+// @Param counter_account_title_id formData int false "Account Title ID of the counter account when no rule matches"
+// @Success 200 {string} string	"Statement was imported"
+// @Failure 400 {string} string	"Request is failed or Statement is invalid"
+// @Router /book/{bid}/import/qif [post]
+func ImportQIF(c *gin.Context) {
+	form, ok := bindStatementImport(c)
+	if !ok {
+		return
+	}
+	defer form.file.Close()
+
+	lines, err := util.ParseQIF(form.file, strings.ToLower(c.DefaultPostForm("encoding", util.CSVEncodingUTF8)))
+	importStatementLines(c, &form, lines, err)
+}
@@ -0,0 +1,125 @@
+package endpoint
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Prokuma/PLAccounting-Backend/crud"
+	util "github.com/Prokuma/PLAccounting-Backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// ForgotPassword godoc
+// @Summary Forgot Password
+// @Tags User
+// @Description Send the mail to reset the password. The response is the same whether the email is registered or not.
+// @Accept  json
+// @Produce  json
+// @Param user body ForgotPasswordRequest true "Forgot Password"
+// @Success 200 {string} string	"Password Reset Requested"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /password/forgot [post]
+func ForgotPassword(c *gin.Context) {
+	var forgotPassword ForgotPasswordRequest
+	err := c.BindJSON(&forgotPassword)
+
+	if err != nil {
+		c.String(http.StatusBadRequest, "Request was failed ")
+		c.Abort()
+		return
+	}
+
+	// Does not tell whether the email is registered
+	user, err := crud.GetUserFromEmail(forgotPassword.Email)
+	if err == nil {
+		token, err := util.GeneratePasswordResetToken(user.UserId)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Making Token was failed")
+			c.Abort()
+			return
+		}
+
+		err = util.SendPasswordResetMail(user.Email, token)
+		if err != nil {
+			fmt.Println("Password reset mail could not sent: ", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password Reset Requested",
+	})
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ResetPassword godoc
+// @Summary Reset Password
+// @Tags User
+// @Description Reset the password with the token of the mail. The token can be used only once, and all the sessions of the user are logged out.
+// @Accept  json
+// @Produce  json
+// @Param user body ResetPasswordRequest true "Reset Password"
+// @Success 200 {string} string	"Password was reset"
+// @Failure 400 {string} string	"Request is failed or Token is invalid"
+// @Router /password/reset [post]
+func ResetPassword(c *gin.Context) {
+	var resetPassword ResetPasswordRequest
+	err := c.BindJSON(&resetPassword)
+
+	if err != nil {
+		c.String(http.StatusBadRequest, "Request was failed ")
+		c.Abort()
+		return
+	}
+
+	userId, err := util.ConsumePasswordResetToken(resetPassword.Token)
+	if err == util.InvalidPasswordResetTokenError {
+		c.String(http.StatusBadRequest, "Token is invalid")
+		c.Abort()
+		return
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Password could not reset")
+		c.Abort()
+		return
+	}
+
+	user, err := crud.GetUser(userId)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Token is invalid")
+		c.Abort()
+		return
+	}
+
+	hash, err := util.HashPassword(resetPassword.Password)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Hashing Password was failed")
+		c.Abort()
+		return
+	}
+
+	err = crud.UpdateUserPassword(&user, hash)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Password could not reset")
+		c.Abort()
+		return
+	}
+
+	err = util.DeleteUserSessions(user.UserId, "")
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Sessions could not logged out")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password was reset",
+	})
+}
@@ -0,0 +1,387 @@
+package endpoint
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Prokuma/PLAccounting-Backend/crud"
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Number of the upcoming occurrences in the response
+const upcomingOccurrences = 5
+
+// Rule is RRULE like FREQ=MONTHLY;BYMONTHDAY=27 (FREQ is DAILY, WEEKLY, MONTHLY or YEARLY,
+// with INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY and BYMONTH). Occurrences are counted from StartsAt.
+type CreateRecurringTransactionRequest struct {
+	Description     string                          `json:"description" binding:"required"`
+	Rule            string                          `json:"rule" binding:"required"`
+	StartsAt        time.Time                       `json:"starts_at" binding:"required"`
+	AsDraft         bool                            `json:"as_draft"`
+	IsEnabled       *bool                           `json:"is_enabled"`
+	SubTransactions []model.RecurringSubTransaction `json:"sub_transactions" binding:"required"`
+}
+
+// Responds the error of crud.CreateRecurringTransaction or crud.UpdateRecurringTransaction, returns false if there is no error
+func abortWithRecurringTransactionError(c *gin.Context, err error, message string) bool {
+	if err == nil {
+		return false
+	}
+
+	var validationError *crud.TransactionValidationError
+	if errors.As(err, &validationError) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"errors":  validationError.Errors,
+			"message": "Recurring Transaction is invalid",
+		})
+	} else if err == crud.InvalidRecurrenceRuleError {
+		c.String(http.StatusBadRequest, "Rule is invalid")
+	} else {
+		c.String(http.StatusInternalServerError, message)
+	}
+	c.Abort()
+	return true
+}
+
+// CreateRecurringTransaction godoc
+// @Summary Create Recurring Transaction
+// @Tags RecurringTransaction
+// @Description Create Recurring Transaction which is created by the scheduler on the dates of the rule, optionally as drafts
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param recurringTransaction body CreateRecurringTransactionRequest true "Create Recurring Transaction"
+// @Success 200 {string} string	"Recurring Transaction was created"
+// @Failure 400 {string} string	"Request is failed or Recurring Transaction is invalid"
+// @Router /book/{bid}/recurring [post]
+func CreateRecurringTransaction(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "write") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	var createRecurring CreateRecurringTransactionRequest
+	err = c.BindJSON(&createRecurring)
+
+	if err != nil {
+		c.String(http.StatusBadRequest, "Infection Informations")
+		c.Abort()
+		return
+	}
+
+	recurring := model.RecurringTransaction{
+		BookId:          book.BookId,
+		Description:     createRecurring.Description,
+		Rule:            createRecurring.Rule,
+		StartsAt:        createRecurring.StartsAt,
+		AsDraft:         createRecurring.AsDraft,
+		IsEnabled:       true,
+		SubTransactions: createRecurring.SubTransactions,
+	}
+	if createRecurring.IsEnabled != nil {
+		recurring.IsEnabled = *createRecurring.IsEnabled
+	}
+
+	err = crud.CreateRecurringTransaction(&recurring)
+	if abortWithRecurringTransactionError(c, err, "Recurring Transaction could not created") {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recurring_transaction": recurring,
+		"upcoming":              crud.UpcomingOccurrences(&recurring, upcomingOccurrences),
+		"message":               "Recurring Transaction was created",
+	})
+}
+
+// GetRecurringTransactions godoc
+// @Summary Get Recurring Transactions
+// @Tags RecurringTransaction
+// @Description Get Recurring Transactions
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Success 200 {string} string	"Recurring Transactions was found"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/recurring [get]
+func GetRecurringTransactions(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "read") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	recurrings, err := crud.GetRecurringTransactions(&book)
+	if err != nil {
+		c.String(http.StatusNotFound, "Recurring Transactions could not found")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recurring_transactions": recurrings,
+		"message":                "Recurring Transactions was found",
+	})
+}
+
+// GetRecurringTransaction godoc
+// @Summary Get Recurring Transaction
+// @Tags RecurringTransaction
+// @Description Get Recurring Transaction with the upcoming occurrences
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param rid path string true "Recurring Transaction ID"
+// @Success 200 {string} string	"Recurring Transaction was found"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/recurring/{rid} [get]
+func GetRecurringTransaction(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "read") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	recurringId, err := strconv.ParseUint(c.Param("rid"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Recurring Transaction ID is invalid")
+		c.Abort()
+		return
+	}
+
+	recurring, err := crud.GetRecurringTransaction(&book, recurringId)
+	if err != nil {
+		c.String(http.StatusNotFound, "Recurring Transaction was not found")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recurring_transaction": recurring,
+		"upcoming":              crud.UpcomingOccurrences(&recurring, upcomingOccurrences),
+		"message":               "Recurring Transaction was found",
+	})
+}
+
+// Changing the rule or StartsAt does not create the occurrences before the last created one again
+type UpdateRecurringTransactionRequest struct {
+	Description     *string                          `json:"description"`
+	Rule            *string                          `json:"rule"`
+	StartsAt        *time.Time                       `json:"starts_at"`
+	AsDraft         *bool                            `json:"as_draft"`
+	IsEnabled       *bool                            `json:"is_enabled"`
+	SubTransactions *[]model.RecurringSubTransaction `json:"sub_transactions"`
+}
+
+// UpdateRecurringTransaction godoc
+// @Summary Update Recurring Transaction
+// @Tags RecurringTransaction
+// @Description Update Recurring Transaction. The transactions already created are not changed.
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param rid path string true "Recurring Transaction ID"
+// @Param recurringTransaction body UpdateRecurringTransactionRequest true "Update Recurring Transaction"
+// @Success 200 {string} string	"Recurring Transaction was updated"
+// @Failure 400 {string} string	"Request is failed or Recurring Transaction is invalid"
+// @Router /book/{bid}/recurring/{rid} [patch]
+func UpdateRecurringTransaction(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "update") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	recurringId, err := strconv.ParseUint(c.Param("rid"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Recurring Transaction ID is invalid")
+		c.Abort()
+		return
+	}
+
+	recurring, err := crud.GetRecurringTransaction(&book, recurringId)
+	if err != nil {
+		c.String(http.StatusNotFound, "Recurring Transaction was not found")
+		c.Abort()
+		return
+	}
+
+	var updateRecurring UpdateRecurringTransactionRequest
+	err = c.BindJSON(&updateRecurring)
+
+	if err != nil {
+		c.String(http.StatusBadRequest, "Infection Informations")
+		c.Abort()
+		return
+	}
+
+	if updateRecurring.Description != nil {
+		recurring.Description = *updateRecurring.Description
+	}
+	if updateRecurring.Rule != nil {
+		recurring.Rule = *updateRecurring.Rule
+	}
+	if updateRecurring.StartsAt != nil {
+		recurring.StartsAt = *updateRecurring.StartsAt
+	}
+	if updateRecurring.AsDraft != nil {
+		recurring.AsDraft = *updateRecurring.AsDraft
+	}
+	if updateRecurring.IsEnabled != nil {
+		recurring.IsEnabled = *updateRecurring.IsEnabled
+		if recurring.IsEnabled {
+			recurring.LastError = ""
+		}
+	}
+	if updateRecurring.SubTransactions != nil {
+		recurring.SubTransactions = *updateRecurring.SubTransactions
+	}
+
+	err = crud.UpdateRecurringTransaction(&recurring)
+	if abortWithRecurringTransactionError(c, err, "Recurring Transaction could not updated") {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recurring_transaction": recurring,
+		"upcoming":              crud.UpcomingOccurrences(&recurring, upcomingOccurrences),
+		"message":               "Recurring Transaction was updated",
+	})
+}
+
+// DeleteRecurringTransaction godoc
+// @Summary Delete Recurring Transaction
+// @Tags RecurringTransaction
+// @Description Delete Recurring Transaction. The transactions already created are not deleted.
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param rid path string true "Recurring Transaction ID"
+// @Success 200 {string} string	"Recurring Transaction was deleted"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/recurring/{rid} [delete]
+func DeleteRecurringTransaction(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "delete") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	recurringId, err := strconv.ParseUint(c.Param("rid"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Recurring Transaction ID is invalid")
+		c.Abort()
+		return
+	}
+
+	err = crud.DeleteRecurringTransaction(&book, recurringId)
+	if err != nil {
+		c.String(http.StatusNotFound, "Recurring Transaction could not delete")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Recurring Transaction was deleted",
+	})
+}
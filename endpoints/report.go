@@ -0,0 +1,348 @@
+package endpoint
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Prokuma/PLAccounting-Backend/crud"
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+const dateLayout = "2006-01-02"
+
+// Returns the period [from, to) from the from and to query parameters.
+// Both dates are inclusive in the query and default to the year of the book.
+func getDateRange(c *gin.Context, book *model.Book) (time.Time, time.Time, error) {
+	from := time.Date(int(book.Year), time.January, 1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(1, 0, 0)
+
+	if fromQuery := c.Query("from"); fromQuery != "" {
+		parsed, err := time.ParseInLocation(dateLayout, fromQuery, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		from = parsed
+	}
+	if toQuery := c.Query("to"); toQuery != "" {
+		parsed, err := time.ParseInLocation(dateLayout, toQuery, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	return from, to, nil
+}
+
+// GetTrialBalance godoc
+// @Summary Get Trial Balance
+// @Tags Report
+// @Description Get Trial Balance of the period
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param from query string false "From (YYYY-MM-DD)"
+// @Param to query string false "To (YYYY-MM-DD)"
+// @Success 200 {string} string	"Trial Balance was generated"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/report/trialBalance [get]
+func GetTrialBalance(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "read") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	from, to, err := getDateRange(c, &book)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Date is invalid")
+		c.Abort()
+		return
+	}
+
+	trialBalance, err := crud.GetTrialBalance(&book, from, to)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Trial Balance could not generated")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trial_balance": trialBalance,
+		"message":       "Trial Balance was generated",
+	})
+}
+
+// GetBalanceSheet godoc
+// @Summary Get Balance Sheet
+// @Tags Report
+// @Description Get Balance Sheet (貸借対照表) at the end of the period
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param to query string false "To (YYYY-MM-DD)"
+// @Success 200 {string} string	"Balance Sheet was generated"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/report/balanceSheet [get]
+func GetBalanceSheet(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "read") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	_, to, err := getDateRange(c, &book)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Date is invalid")
+		c.Abort()
+		return
+	}
+
+	balanceSheet, err := crud.GetBalanceSheet(&book, to)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Balance Sheet could not generated")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"balance_sheet": balanceSheet,
+		"message":       "Balance Sheet was generated",
+	})
+}
+
+// GetProfitAndLoss godoc
+// @Summary Get Profit and Loss
+// @Tags Report
+// @Description Get Profit and Loss Statement (損益計算書) of the period
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param from query string false "From (YYYY-MM-DD)"
+// @Param to query string false "To (YYYY-MM-DD)"
+// @Success 200 {string} string	"Profit and Loss was generated"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/report/profitAndLoss [get]
+func GetProfitAndLoss(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "read") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	from, to, err := getDateRange(c, &book)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Date is invalid")
+		c.Abort()
+		return
+	}
+
+	profitAndLoss, err := crud.GetProfitAndLoss(&book, from, to)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Profit and Loss could not generated")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"profit_and_loss": profitAndLoss,
+		"message":         "Profit and Loss was generated",
+	})
+}
+
+// GetLedger godoc
+// @Summary Get Ledger
+// @Tags Report
+// @Description Get General Ledger (総勘定元帳) of the account title with running balance
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param tid path string true "Account Title ID"
+// @Param from query string false "From (YYYY-MM-DD)"
+// @Param to query string false "To (YYYY-MM-DD)"
+// @Success 200 {string} string	"Ledger was generated"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/accountTitle/{tid}/ledger [get]
+func GetLedger(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "read") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	accountTitleId, err := strconv.ParseUint(c.Param("tid"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Account Title ID is invalid")
+		c.Abort()
+		return
+	}
+
+	from, to, err := getDateRange(c, &book)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Date is invalid")
+		c.Abort()
+		return
+	}
+
+	ledger, err := crud.GetLedger(&book, accountTitleId, from, to)
+	if err != nil {
+		c.String(http.StatusNotFound, "Ledger could not generated")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ledger":  ledger,
+		"message": "Ledger was generated",
+	})
+}
+
+// CheckBalances godoc
+// @Summary Check Balances
+// @Tags Balance Check
+// @Description Recompute balances of account titles from the sub transactions and report discrepancies
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Success 200 {string} string	"Balances was checked"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/balanceCheck [get]
+func CheckBalances(c *gin.Context) {
+	checkBalances(c, false)
+}
+
+// RepairBalances godoc
+// @Summary Repair Balances
+// @Tags Balance Check
+// @Description Recompute balances of account titles from the sub transactions and overwrite the discrepancies
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Success 200 {string} string	"Balances was repaired"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/balanceCheck [post]
+func RepairBalances(c *gin.Context) {
+	checkBalances(c, true)
+}
+
+func checkBalances(c *gin.Context, repair bool) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "admin") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	discrepancies, err := crud.CheckBalances(&book, repair)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Balances could not checked")
+		c.Abort()
+		return
+	}
+
+	message := "Balances was checked"
+	if repair {
+		message = "Balances was repaired"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"discrepancies": discrepancies,
+		"message":       message,
+	})
+}
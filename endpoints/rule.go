@@ -0,0 +1,422 @@
+package endpoint
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Prokuma/PLAccounting-Backend/crud"
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Empty conditions match everything. DescriptionPattern is a regular expression
+// and DescriptionRewrite can refer to its groups like $1.
+type CreateRuleRequest struct {
+	Name                  string  `json:"name" binding:"required"`
+	Priority              int     `json:"priority"`
+	IsEnabled             *bool   `json:"is_enabled"`
+	DescriptionContains   string  `json:"description_contains"`
+	DescriptionPattern    string  `json:"description_pattern"`
+	MinAmount             *int64  `json:"min_amount"`
+	MaxAmount             *int64  `json:"max_amount"`
+	SourceAccountTitleId  *uint64 `json:"source_account_title_id"`
+	CounterAccountTitleId uint64  `json:"counter_account_title_id" binding:"required"`
+	DescriptionRewrite    string  `json:"description_rewrite"`
+}
+
+// CreateRule godoc
+// @Summary Create Rule
+// @Tags Rule
+// @Description Create Rule which completes the counter account of imported or created transactions with only one line
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param rule body CreateRuleRequest true "Create Rule"
+// @Success 200 {string} string	"Rule was created"
+// @Failure 400 {string} string	"Request is failed or Rule is invalid"
+// @Router /book/{bid}/rule [post]
+func CreateRule(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "admin") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	var createRule CreateRuleRequest
+	err = c.BindJSON(&createRule)
+
+	if err != nil {
+		c.String(http.StatusBadRequest, "Infection Informations")
+		c.Abort()
+		return
+	}
+
+	rule := model.Rule{
+		BookId:                book.BookId,
+		Name:                  createRule.Name,
+		Priority:              createRule.Priority,
+		IsEnabled:             true,
+		DescriptionContains:   createRule.DescriptionContains,
+		DescriptionPattern:    createRule.DescriptionPattern,
+		MinAmount:             createRule.MinAmount,
+		MaxAmount:             createRule.MaxAmount,
+		SourceAccountTitleId:  createRule.SourceAccountTitleId,
+		CounterAccountTitleId: createRule.CounterAccountTitleId,
+		DescriptionRewrite:    createRule.DescriptionRewrite,
+	}
+	if createRule.IsEnabled != nil {
+		rule.IsEnabled = *createRule.IsEnabled
+	}
+
+	err = crud.CreateRule(&rule)
+	if err == crud.InvalidRuleError {
+		c.String(http.StatusBadRequest, "Rule is invalid")
+		c.Abort()
+		return
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Rule could not created")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rule":    rule,
+		"message": "Rule was created",
+	})
+}
+
+// GetRules godoc
+// @Summary Get Rules
+// @Tags Rule
+// @Description Get Rules in the order of the priority
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Success 200 {string} string	"Rules was found"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/rule [get]
+func GetRules(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "read") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	rules, err := crud.GetRules(&book)
+	if err != nil {
+		c.String(http.StatusNotFound, "Rules could not found")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules":   rules,
+		"message": "Rules was found",
+	})
+}
+
+// SourceAccountTitleId 0 clears the source account
+type UpdateRuleRequest struct {
+	Name                  *string `json:"name"`
+	Priority              *int    `json:"priority"`
+	IsEnabled             *bool   `json:"is_enabled"`
+	DescriptionContains   *string `json:"description_contains"`
+	DescriptionPattern    *string `json:"description_pattern"`
+	MinAmount             *int64  `json:"min_amount"`
+	MaxAmount             *int64  `json:"max_amount"`
+	SourceAccountTitleId  *uint64 `json:"source_account_title_id"`
+	CounterAccountTitleId *uint64 `json:"counter_account_title_id"`
+	DescriptionRewrite    *string `json:"description_rewrite"`
+}
+
+// UpdateRule godoc
+// @Summary Update Rule
+// @Tags Rule
+// @Description Update Rule
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param rid path string true "Rule ID"
+// @Param rule body UpdateRuleRequest true "Update Rule"
+// @Success 200 {string} string	"Rule was updated"
+// @Failure 400 {string} string	"Request is failed or Rule is invalid"
+// @Router /book/{bid}/rule/{rid} [patch]
+func UpdateRule(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "update") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	ruleId, err := strconv.ParseUint(c.Param("rid"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Rule ID is invalid")
+		c.Abort()
+		return
+	}
+
+	rule, err := crud.GetRule(&book, ruleId)
+	if err != nil {
+		c.String(http.StatusNotFound, "Rule was not found")
+		c.Abort()
+		return
+	}
+
+	var updateRule UpdateRuleRequest
+	err = c.BindJSON(&updateRule)
+
+	if err != nil {
+		c.String(http.StatusBadRequest, "Infection Informations")
+		c.Abort()
+		return
+	}
+
+	if updateRule.Name != nil {
+		rule.Name = *updateRule.Name
+	}
+	if updateRule.Priority != nil {
+		rule.Priority = *updateRule.Priority
+	}
+	if updateRule.IsEnabled != nil {
+		rule.IsEnabled = *updateRule.IsEnabled
+	}
+	if updateRule.DescriptionContains != nil {
+		rule.DescriptionContains = *updateRule.DescriptionContains
+	}
+	if updateRule.DescriptionPattern != nil {
+		rule.DescriptionPattern = *updateRule.DescriptionPattern
+	}
+	if updateRule.MinAmount != nil {
+		rule.MinAmount = updateRule.MinAmount
+	}
+	if updateRule.MaxAmount != nil {
+		rule.MaxAmount = updateRule.MaxAmount
+	}
+	if updateRule.SourceAccountTitleId != nil {
+		if *updateRule.SourceAccountTitleId == 0 {
+			rule.SourceAccountTitleId = nil
+		} else {
+			rule.SourceAccountTitleId = updateRule.SourceAccountTitleId
+		}
+	}
+	if updateRule.CounterAccountTitleId != nil {
+		rule.CounterAccountTitleId = *updateRule.CounterAccountTitleId
+	}
+	if updateRule.DescriptionRewrite != nil {
+		rule.DescriptionRewrite = *updateRule.DescriptionRewrite
+	}
+
+	err = crud.UpdateRule(&rule)
+	if err == crud.InvalidRuleError {
+		c.String(http.StatusBadRequest, "Rule is invalid")
+		c.Abort()
+		return
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Rule could not updated")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rule":    rule,
+		"message": "Rule was updated",
+	})
+}
+
+// DeleteRule godoc
+// @Summary Delete Rule
+// @Tags Rule
+// @Description Delete Rule
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param rid path string true "Rule ID"
+// @Success 200 {string} string	"Rule was deleted"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/rule/{rid} [delete]
+func DeleteRule(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "admin") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	ruleId, err := strconv.ParseUint(c.Param("rid"), 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Rule ID is invalid")
+		c.Abort()
+		return
+	}
+
+	err = crud.DeleteRule(&book, ruleId)
+	if err != nil {
+		c.String(http.StatusNotFound, "Rule could not delete")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Rule was deleted",
+	})
+}
+
+// DryRunRules godoc
+// @Summary Dry Run Rules
+// @Tags Rule
+// @Description Find existing transactions with only one line which the rules would complete without changing them. Each transaction is reported with the first matching rule.
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param rule_id query int false "Rule ID (all enabled rules by default)"
+// @Param limit query int false "Limit of matches (default 100, max 100)"
+// @Param from query string false "From (YYYY-MM-DD)"
+// @Param to query string false "To (YYYY-MM-DD)"
+// @Param account_title_id query []int false "Account Title IDs" collectionFormat(multi)
+// @Param min_amount query int false "Minimum amount of a sub transaction"
+// @Param max_amount query int false "Maximum amount of a sub transaction"
+// @Param side query string false "debit or credit"
+// @Param description query string false "Substring of the description"
+// @Success 200 {string} string	"Rules was dry run"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /book/{bid}/rule/dryRun [get]
+func DryRunRules(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "read") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	var ruleId uint64
+	if ruleIdQuery := c.Query("rule_id"); ruleIdQuery != "" {
+		ruleId, err = strconv.ParseUint(ruleIdQuery, 10, 64)
+		if err != nil {
+			c.String(http.StatusBadRequest, "Rule ID is invalid")
+			c.Abort()
+			return
+		}
+	}
+
+	pagination, err := getPagination(c, maxPageLimit)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Limit is invalid")
+		c.Abort()
+		return
+	}
+
+	filter, err := getTransactionFilter(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Filter is invalid")
+		c.Abort()
+		return
+	}
+
+	matches, err := crud.DryRunRules(&book, ruleId, &filter, pagination.Limit)
+	if err != nil {
+		c.String(http.StatusNotFound, "Rules could not dry run")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"matches": matches,
+		"message": "Rules was dry run",
+	})
+}
@@ -0,0 +1,104 @@
+package endpoint
+
+import (
+	"net/http"
+
+	util "github.com/Prokuma/PLAccounting-Backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GetSessions godoc
+// @Summary Get Sessions
+// @Tags User
+// @Description Get the logged in sessions of the User. current_session_id is the session of the request.
+// @Accept  json
+// @Produce  json
+// @Success 200 {string} string	"Sessions was found"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /session [get]
+func GetSessions(c *gin.Context) {
+	user, session, err := getSessionFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	sessions, err := util.GetUserSessions(user.UserId)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Sessions could not found")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions":           sessions,
+		"current_session_id": session.SessionId,
+		"message":            "Sessions was found",
+	})
+}
+
+// DeleteSession godoc
+// @Summary Delete Session
+// @Tags User
+// @Description Log out the session and revoke its token
+// @Accept  json
+// @Produce  json
+// @Param sid path string true "Session ID"
+// @Success 200 {string} string	"Session was deleted"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /session/{sid} [delete]
+func DeleteSession(c *gin.Context) {
+	user, session, err := getSessionFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	err = util.DeleteSession(user.UserId, c.Param("sid"))
+	if err == util.InvalidSessionError {
+		c.String(http.StatusNotFound, "Session was not found")
+		c.Abort()
+		return
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Session could not delete")
+		c.Abort()
+		return
+	}
+
+	if c.Param("sid") == session.SessionId {
+		clearTokenCookies(c)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Session was deleted",
+	})
+}
+
+// DeleteAllSessions godoc
+// @Summary Delete All Sessions
+// @Tags User
+// @Description Log out everywhere including the current session
+// @Accept  json
+// @Produce  json
+// @Success 200 {string} string	"Sessions was deleted"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /session [delete]
+func DeleteAllSessions(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	err = util.DeleteUserSessions(user.UserId, "")
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Sessions could not delete")
+		c.Abort()
+		return
+	}
+
+	clearTokenCookies(c)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sessions was deleted",
+	})
+}
@@ -1,6 +1,7 @@
 package endpoint
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,10 +12,12 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// Status is posted (default) or draft. Drafts do not change the balances until they are posted.
 type CreateTransactionRequest struct {
-	Description     string                 `json:"description" binding:"required"`
-	OccurredAt      time.Time              `json:"occurred_at" binding:"required"`
-	SubTransactions []model.SubTransaction `json:"sub_transactions" binding:"required"`
+	Description     string                  `json:"description" binding:"required"`
+	OccurredAt      time.Time               `json:"occurred_at" binding:"required"`
+	SubTransactions []model.SubTransaction  `json:"sub_transactions" binding:"required"`
+	Status          model.TransactionStatus `json:"status"`
 }
 
 // CreateTransaction godoc
@@ -26,7 +29,7 @@ type CreateTransactionRequest struct {
 // @Param bid path string true "Book ID"
 // @Param transaction body CreateTransactionRequest true "Create Transaction"
 // @Success 200 {string} string	"Created Transaction"
-// @Failure 400 {string} string	"Request is failed"
+// @Failure 400 {string} string	"Request is failed or Transaction is invalid"
 // @Router /book/{bid}/transaction [post]
 func CreateTransaction(c *gin.Context) {
 	user, err := getUserIdFromJWT(c)
@@ -63,6 +66,15 @@ func CreateTransaction(c *gin.Context) {
 		return
 	}
 
+	if createTransaction.Status == "" {
+		createTransaction.Status = model.TransactionStatusPosted
+	}
+	if !createTransaction.Status.IsValid() {
+		c.String(http.StatusBadRequest, "Status is invalid")
+		c.Abort()
+		return
+	}
+
 	for idx := range createTransaction.SubTransactions {
 		createTransaction.SubTransactions[idx].BookId = book.BookId
 	}
@@ -72,10 +84,20 @@ func CreateTransaction(c *gin.Context) {
 		Description:     createTransaction.Description,
 		OccurredAt:      createTransaction.OccurredAt,
 		SubTransactions: createTransaction.SubTransactions,
+		Status:          createTransaction.Status,
 	}
 
 	err = crud.CreateTransaction(&transaction)
 	if err != nil {
+		var validationError *crud.TransactionValidationError
+		if errors.As(err, &validationError) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"errors":  validationError.Errors,
+				"message": "Transaction is invalid",
+			})
+			c.Abort()
+			return
+		}
 		c.String(http.StatusInternalServerError, "Transactiuon could not created")
 		c.Abort()
 		return
@@ -87,6 +109,100 @@ func CreateTransaction(c *gin.Context) {
 	})
 }
 
+const maxBatchTransactions = 1000
+
+// Each transaction is the same as CreateTransactionRequest
+type CreateTransactionsRequest struct {
+	Transactions []CreateTransactionRequest `json:"transactions" binding:"required"`
+}
+
+// CreateTransactions godoc
+// @Summary Create Transactions
+// @Tags Transaction
+// @Description Create up to 1000 Transactions at once. All the transactions are created or none of them, and the errors are reported per transaction.
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param transactions body CreateTransactionsRequest true "Create Transactions"
+// @Success 200 {string} string	"Transactions was created"
+// @Failure 400 {string} string	"Request is failed or Transactions are invalid"
+// @Router /book/{bid}/transaction/batch [post]
+func CreateTransactions(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "write") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	var createTransactions CreateTransactionsRequest
+	err = c.BindJSON(&createTransactions)
+
+	if err != nil {
+		c.String(http.StatusBadRequest, "Infection Informations")
+		c.Abort()
+		return
+	}
+	if len(createTransactions.Transactions) > maxBatchTransactions {
+		c.String(http.StatusBadRequest, "Too many Transactions")
+		c.Abort()
+		return
+	}
+
+	transactions := make([]model.Transaction, 0, len(createTransactions.Transactions))
+	for _, createTransaction := range createTransactions.Transactions {
+		transactions = append(transactions, model.Transaction{
+			Description:     createTransaction.Description,
+			OccurredAt:      createTransaction.OccurredAt,
+			SubTransactions: createTransaction.SubTransactions,
+			Status:          createTransaction.Status,
+		})
+	}
+
+	err = crud.CreateTransactions(&book, transactions)
+	if err != nil {
+		var batchError *crud.TransactionBatchError
+		if errors.As(err, &batchError) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"items":   batchError.Items,
+				"message": "Transactions are invalid",
+			})
+			c.Abort()
+			return
+		}
+		c.String(http.StatusInternalServerError, "Transactions could not created")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": transactions,
+		"count":        len(transactions),
+		"message":      "Transactions was created",
+	})
+}
+
+// SubTransactions replaces all lines of the transaction.
+// Lines without sub_transaction_id are added and lines not in the request are deleted.
+// The status can not be changed, drafts are posted by POST /book/{bid}/transaction/post.
 type UpdateTransactionRequest struct {
 	Description     *string                 `json:"description"`
 	OccurredAt      *time.Time              `json:"occurred_at"`
@@ -103,7 +219,7 @@ type UpdateTransactionRequest struct {
 // @Param tid path string true "Transaction ID"
 // @Param transaction body UpdateTransactionRequest true "Update Transaction"
 // @Success 200 {string} string	"Updated Transaction"
-// @Failure 400 {string} string	"Request is failed"
+// @Failure 400 {string} string	"Request is failed or Transaction is invalid"
 // @Router /book/{bid}/transaction/{tid} [patch]
 func UpdateTransaction(c *gin.Context) {
 	user, err := getUserIdFromJWT(c)
@@ -150,6 +266,8 @@ func UpdateTransaction(c *gin.Context) {
 	transaction, err := crud.GetTransaction(&book, transactionId)
 	if err != nil {
 		c.String(http.StatusBadRequest, "Transaction ID is invalid")
+		c.Abort()
+		return
 	}
 
 	if updateTransaction.Description != nil {
@@ -167,6 +285,15 @@ func UpdateTransaction(c *gin.Context) {
 
 	err = crud.UpdateTransaction(&transaction)
 	if err != nil {
+		var validationError *crud.TransactionValidationError
+		if errors.As(err, &validationError) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"errors":  validationError.Errors,
+				"message": "Transaction is invalid",
+			})
+			c.Abort()
+			return
+		}
 		c.String(http.StatusInternalServerError, "Transactiuon could not created")
 		c.Abort()
 		return
@@ -235,6 +362,101 @@ func GetTransaction(c *gin.Context) {
 	})
 }
 
+const defaultPageLimit = 20
+const maxPageLimit = 100
+
+// Reads the limit and cursor query parameters.
+// defaultLimit 0 means no limit unless the limit is given.
+func getPagination(c *gin.Context, defaultLimit int) (crud.Pagination, error) {
+	pagination := crud.Pagination{
+		Limit:  defaultLimit,
+		Cursor: c.Query("cursor"),
+	}
+
+	if limitQuery := c.Query("limit"); limitQuery != "" {
+		limit, err := strconv.Atoi(limitQuery)
+		if err != nil {
+			return crud.Pagination{}, err
+		}
+		if limit < 1 || limit > maxPageLimit {
+			return crud.Pagination{}, errors.New("Invalid Limit")
+		}
+		pagination.Limit = limit
+	}
+
+	return pagination, nil
+}
+
+// Reads the filter of transactions from the query parameters.
+// account_title_id can be repeated or separated by commas.
+func getTransactionFilter(c *gin.Context) (crud.TransactionFilter, error) {
+	var filter crud.TransactionFilter
+
+	if fromQuery := c.Query("from"); fromQuery != "" {
+		from, err := time.ParseInLocation(dateLayout, fromQuery, time.Local)
+		if err != nil {
+			return crud.TransactionFilter{}, err
+		}
+		filter.From = &from
+	}
+	if toQuery := c.Query("to"); toQuery != "" {
+		to, err := time.ParseInLocation(dateLayout, toQuery, time.Local)
+		if err != nil {
+			return crud.TransactionFilter{}, err
+		}
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	for _, accountTitleIdsQuery := range c.QueryArray("account_title_id") {
+		for _, accountTitleIdQuery := range strings.Split(accountTitleIdsQuery, ",") {
+			accountTitleId, err := strconv.ParseUint(strings.TrimSpace(accountTitleIdQuery), 10, 64)
+			if err != nil {
+				return crud.TransactionFilter{}, err
+			}
+			filter.AccountTitleIds = append(filter.AccountTitleIds, accountTitleId)
+		}
+	}
+
+	if minAmountQuery := c.Query("min_amount"); minAmountQuery != "" {
+		minAmount, err := strconv.ParseInt(minAmountQuery, 10, 64)
+		if err != nil {
+			return crud.TransactionFilter{}, err
+		}
+		filter.MinAmount = &minAmount
+	}
+	if maxAmountQuery := c.Query("max_amount"); maxAmountQuery != "" {
+		maxAmount, err := strconv.ParseInt(maxAmountQuery, 10, 64)
+		if err != nil {
+			return crud.TransactionFilter{}, err
+		}
+		filter.MaxAmount = &maxAmount
+	}
+
+	switch c.Query("side") {
+	case "":
+	case "debit":
+		isDebit := true
+		filter.IsDebit = &isDebit
+	case "credit":
+		isDebit := false
+		filter.IsDebit = &isDebit
+	default:
+		return crud.TransactionFilter{}, errors.New("Invalid Side")
+	}
+
+	filter.Description = c.Query("description")
+
+	if statusQuery := c.Query("status"); statusQuery != "" {
+		filter.Status = model.TransactionStatus(statusQuery)
+		if !filter.Status.IsValid() {
+			return crud.TransactionFilter{}, errors.New("Invalid Status")
+		}
+	}
+
+	return filter, nil
+}
+
 // GetTransactions godoc
 // @Summary Get Transactions
 // @Tags Transaction
@@ -242,6 +464,16 @@ func GetTransaction(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param bid path string true "Book ID"
+// @Param from query string false "From (YYYY-MM-DD)"
+// @Param to query string false "To (YYYY-MM-DD)"
+// @Param account_title_id query []int false "Account Title IDs" collectionFormat(multi)
+// @Param min_amount query int false "Minimum amount of a sub transaction"
+// @Param max_amount query int false "Maximum amount of a sub transaction"
+// @Param side query string false "debit or credit"
+// @Param description query string false "Substring of the description"
+// @Param status query string false "posted or draft"
+// @Param limit query int false "Limit (default 20, max 100)"
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {string} string	"Transactions was found"
 // @Failure 400 {string} string	"Request is failed"
 // @Router /book/{bid}/transaction [get]
@@ -271,8 +503,27 @@ func GetTransactions(c *gin.Context) {
 		return
 	}
 
-	transactions, err := crud.GetTransactions(&book, 20, 0)
+	filter, err := getTransactionFilter(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Filter is invalid")
+		c.Abort()
+		return
+	}
+
+	pagination, err := getPagination(c, defaultPageLimit)
 	if err != nil {
+		c.String(http.StatusBadRequest, "Limit is invalid")
+		c.Abort()
+		return
+	}
+
+	transactions, pageInfo, err := crud.GetTransactions(&book, &filter, &pagination)
+	if err != nil {
+		if err == crud.InvalidCursorError {
+			c.String(http.StatusBadRequest, "Cursor is invalid")
+			c.Abort()
+			return
+		}
 		c.String(http.StatusNotFound, "Transactions could not found")
 		c.Abort()
 		return
@@ -280,6 +531,8 @@ func GetTransactions(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"transactions": transactions,
+		"total":        pageInfo.Total,
+		"next_cursor":  pageInfo.NextCursor,
 		"message":      "Transactions was found",
 	})
 }
@@ -292,6 +545,16 @@ func GetTransactions(c *gin.Context) {
 // @Produce  json
 // @Param bid path string true "Book ID"
 // @Param pid path string true "Page ID"
+// @Param from query string false "From (YYYY-MM-DD)"
+// @Param to query string false "To (YYYY-MM-DD)"
+// @Param account_title_id query []int false "Account Title IDs" collectionFormat(multi)
+// @Param min_amount query int false "Minimum amount of a sub transaction"
+// @Param max_amount query int false "Maximum amount of a sub transaction"
+// @Param side query string false "debit or credit"
+// @Param description query string false "Substring of the description"
+// @Param status query string false "posted or draft"
+// @Param limit query int false "Limit (default 20, max 100)"
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {string} string	"Transactions was found"
 // @Failure 400 {string} string	"Request is failed"
 // @Router /book/{bid}/transaction/page/{pid} [get]
@@ -328,8 +591,28 @@ func GetTransactionsWithPage(c *gin.Context) {
 		return
 	}
 
-	transactions, err := crud.GetTransactions(&book, 20, page)
+	filter, err := getTransactionFilter(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Filter is invalid")
+		c.Abort()
+		return
+	}
+
+	pagination, err := getPagination(c, defaultPageLimit)
 	if err != nil {
+		c.String(http.StatusBadRequest, "Limit is invalid")
+		c.Abort()
+		return
+	}
+	pagination.Page = page
+
+	transactions, pageInfo, err := crud.GetTransactions(&book, &filter, &pagination)
+	if err != nil {
+		if err == crud.InvalidCursorError {
+			c.String(http.StatusBadRequest, "Cursor is invalid")
+			c.Abort()
+			return
+		}
 		c.String(http.StatusNotFound, "Transactions could not found")
 		c.Abort()
 		return
@@ -337,6 +620,8 @@ func GetTransactionsWithPage(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"transactions": transactions,
+		"total":        pageInfo.Total,
+		"next_cursor":  pageInfo.NextCursor,
 		"message":      "Transactions was found",
 	})
 }
@@ -397,6 +682,78 @@ func DeleteTransaction(c *gin.Context) {
 	})
 }
 
+type PostTransactionsRequest struct {
+	TransactionIds []uint64 `json:"transaction_ids" binding:"required"`
+}
+
+// PostTransactions godoc
+// @Summary Post Transactions
+// @Tags Transaction
+// @Description Post draft Transactions to the balances. A draft with only one line is completed by the rules. All the transactions are posted or none of them.
+// @Accept  json
+// @Produce  json
+// @Param bid path string true "Book ID"
+// @Param transactions body PostTransactionsRequest true "Post Transactions"
+// @Success 200 {string} string	"Transactions was posted"
+// @Failure 400 {string} string	"Request is failed or Transactions are invalid"
+// @Router /book/{bid}/transaction/post [post]
+func PostTransactions(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	book, err := crud.GetBook(c.Param("bid"))
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Book was not found")
+		c.Abort()
+		return
+	}
+
+	bookAuthorization, err := crud.GetBookAuthorization(&user, &book)
+	if err != nil {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+	if strings.Index(bookAuthorization.Authority, "write") == -1 {
+		c.String(http.StatusUnauthorized, NoAuthorizationError.Error())
+		c.Abort()
+		return
+	}
+
+	var postTransactions PostTransactionsRequest
+	err = c.BindJSON(&postTransactions)
+
+	if err != nil {
+		c.String(http.StatusBadRequest, "Infection Informations")
+		c.Abort()
+		return
+	}
+
+	transactions, err := crud.PostTransactions(&book, postTransactions.TransactionIds)
+	if err != nil {
+		var batchError *crud.TransactionBatchError
+		if errors.As(err, &batchError) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"items":   batchError.Items,
+				"message": "Transactions are invalid",
+			})
+			c.Abort()
+			return
+		}
+		c.String(http.StatusInternalServerError, "Transactions could not posted")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": transactions,
+		"message":      "Transactions was posted",
+	})
+}
+
 // GetSubTransactionsFromAccountTitle godoc
 // @Summary Get Sub Transactions from Account Title
 // @Tags Sub Transaction
@@ -405,6 +762,8 @@ func DeleteTransaction(c *gin.Context) {
 // @Produce  json
 // @Param bid path string true "Book ID"
 // @Param tid path string true "Account Title ID"
+// @Param limit query int false "Limit (default 20, max 100)"
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {string} string	"Sub Transactions was found"
 // @Failure 400 {string} string	"Request is failed"
 // @Router /book/{bid}/accountTitle/{tid}/transactions [get]
@@ -441,8 +800,20 @@ func GetSubTransactionsFromAccountTitle(c *gin.Context) {
 		return
 	}
 
-	subTransactions, err := crud.GetSubTransactionsFromAccountTitle(&book, accountTitleId, 20, 0)
+	pagination, err := getPagination(c, defaultPageLimit)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Limit is invalid")
+		c.Abort()
+		return
+	}
+
+	subTransactions, pageInfo, err := crud.GetSubTransactionsFromAccountTitle(&book, accountTitleId, &pagination)
 	if err != nil {
+		if err == crud.InvalidCursorError {
+			c.String(http.StatusBadRequest, "Cursor is invalid")
+			c.Abort()
+			return
+		}
 		c.String(http.StatusNotFound, "Sub Transactions could not found")
 		c.Abort()
 		return
@@ -450,6 +821,8 @@ func GetSubTransactionsFromAccountTitle(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"sub_transactions": subTransactions,
+		"total":            pageInfo.Total,
+		"next_cursor":      pageInfo.NextCursor,
 		"message":          "Sub Transactions was found",
 	})
 }
@@ -463,6 +836,8 @@ func GetSubTransactionsFromAccountTitle(c *gin.Context) {
 // @Param bid path string true "Book ID"
 // @Param tid path string true "Account Title ID"
 // @Param pid path string true "Page ID"
+// @Param limit query int false "Limit (default 20, max 100)"
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {string} string	"Sub Transactions was found"
 // @Failure 400 {string} string	"Request is failed"
 // @Router /book/{bid}/accountTitle/{tid}/transactions/{pid} [get]
@@ -506,8 +881,21 @@ func GetSubTransactionsFromAccountTitleWithPage(c *gin.Context) {
 		return
 	}
 
-	subTransactions, err := crud.GetSubTransactionsFromAccountTitle(&book, accountTitleId, 20, page)
+	pagination, err := getPagination(c, defaultPageLimit)
 	if err != nil {
+		c.String(http.StatusBadRequest, "Limit is invalid")
+		c.Abort()
+		return
+	}
+	pagination.Page = page
+
+	subTransactions, pageInfo, err := crud.GetSubTransactionsFromAccountTitle(&book, accountTitleId, &pagination)
+	if err != nil {
+		if err == crud.InvalidCursorError {
+			c.String(http.StatusBadRequest, "Cursor is invalid")
+			c.Abort()
+			return
+		}
 		c.String(http.StatusNotFound, "Sub Transactions could not found")
 		c.Abort()
 		return
@@ -515,6 +903,8 @@ func GetSubTransactionsFromAccountTitleWithPage(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"sub_transactions": subTransactions,
+		"total":            pageInfo.Total,
+		"next_cursor":      pageInfo.NextCursor,
 		"message":          "Sub Transactions was found",
 	})
 }
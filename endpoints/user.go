@@ -0,0 +1,241 @@
+package endpoint
+
+import (
+	"net/http"
+
+	"github.com/Prokuma/PLAccounting-Backend/crud"
+	util "github.com/Prokuma/PLAccounting-Backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type UpdateUserRequest struct {
+	Name *string `json:"name"`
+}
+
+// UpdateUser godoc
+// @Summary Update User
+// @Tags User
+// @Description Update the name of the User. The email and the password are changed by their own endpoints.
+// @Accept  json
+// @Produce  json
+// @Param user body UpdateUserRequest true "Update User"
+// @Success 200 {string} string	"User was updated"
+// @Failure 400 {string} string	"Request is failed"
+// @Router /user [patch]
+func UpdateUser(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	var updateUser UpdateUserRequest
+	err = c.BindJSON(&updateUser)
+
+	if err != nil {
+		c.String(http.StatusBadRequest, "Infection Informations")
+		c.Abort()
+		return
+	}
+
+	if updateUser.Name != nil {
+		if *updateUser.Name == "" {
+			c.String(http.StatusBadRequest, "Name is invalid")
+			c.Abort()
+			return
+		}
+		user.Name = *updateUser.Name
+	}
+
+	err = crud.UpdateUser(&user)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "User could not updated")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  200,
+		"id":      user.UserId,
+		"email":   user.Email,
+		"name":    user.Name,
+		"message": "User was updated",
+	})
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	Password        string `json:"password" binding:"required"`
+}
+
+// ChangePassword godoc
+// @Summary Change Password
+// @Tags User
+// @Description Change the password with the current password. The other sessions are logged out.
+// @Accept  json
+// @Produce  json
+// @Param user body ChangePasswordRequest true "Change Password"
+// @Success 200 {string} string	"Password was changed"
+// @Failure 400 {string} string	"Request is failed"
+// @Failure 403 {string} string	"Password was not currect"
+// @Router /user/password [post]
+func ChangePassword(c *gin.Context) {
+	user, session, err := getSessionFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	var changePassword ChangePasswordRequest
+	err = c.BindJSON(&changePassword)
+
+	if err != nil {
+		c.String(http.StatusBadRequest, "Infection Informations")
+		c.Abort()
+		return
+	}
+
+	ok, _, err := util.VerifyPassword(changePassword.CurrentPassword, user.Password)
+	if err != nil || !ok {
+		c.String(http.StatusForbidden, "Password was not currect")
+		c.Abort()
+		return
+	}
+
+	hash, err := util.HashPassword(changePassword.Password)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Hashing Password was failed")
+		c.Abort()
+		return
+	}
+
+	err = crud.UpdateUserPassword(&user, hash)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Password could not changed")
+		c.Abort()
+		return
+	}
+
+	err = util.DeleteUserSessions(user.UserId, session.SessionId)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Sessions could not logged out")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  200,
+		"message": "Password was changed",
+	})
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ChangeEmail godoc
+// @Summary Change Email
+// @Tags User
+// @Description Send the confirmation mail to the new email. The email is changed when the link of the mail is opened.
+// @Accept  json
+// @Produce  json
+// @Param user body ChangeEmailRequest true "Change Email"
+// @Success 200 {string} string	"Email Change Requested"
+// @Failure 400 {string} string	"Request is failed"
+// @Failure 403 {string} string	"Password was not currect"
+// @Router /user/email [post]
+func ChangeEmail(c *gin.Context) {
+	user, err := getUserIdFromJWT(c)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	var changeEmail ChangeEmailRequest
+	err = c.BindJSON(&changeEmail)
+
+	if err != nil {
+		c.String(http.StatusBadRequest, "Infection Informations")
+		c.Abort()
+		return
+	}
+
+	if util.ValidateEmail(changeEmail.Email) != nil {
+		c.String(http.StatusBadRequest, "Email is invalid")
+		c.Abort()
+		return
+	}
+
+	ok, _, err := util.VerifyPassword(changeEmail.Password, user.Password)
+	if err != nil || !ok {
+		c.String(http.StatusForbidden, "Password was not currect")
+		c.Abort()
+		return
+	}
+
+	if changeEmail.Email == user.Email {
+		c.String(http.StatusBadRequest, "Email is not changed")
+		c.Abort()
+		return
+	}
+	if _, err := crud.GetUserFromEmail(changeEmail.Email); err == nil {
+		c.String(http.StatusBadRequest, "Request was failed ")
+		c.Abort()
+		return
+	}
+
+	token, err := util.GenerateEmailChangeToken(user.UserId, changeEmail.Email)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Making Token was failed")
+		c.Abort()
+		return
+	}
+
+	err = util.SendEmailChangeMail(changeEmail.Email, token)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Request was failed ")
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  200,
+		"message": "Email Change Requested",
+	})
+}
+
+// ConfirmEmailChange godoc
+// @Summary Confirm Email Change
+// Note: This endpoint is not for API.
+func ConfirmEmailChange(c *gin.Context) {
+	userId, email, err := util.ConsumeEmailChangeToken(c.Query("token"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Request was failed ")
+		c.Abort()
+		return
+	}
+
+	user, err := crud.GetUser(userId)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Request was failed ")
+		c.Abort()
+		return
+	}
+
+	// The email may be registered after the request
+	if _, err := crud.GetUserFromEmail(email); err == nil {
+		c.String(http.StatusBadRequest, "Email was already registered")
+		c.Abort()
+		return
+	}
+
+	err = crud.UpdateUserEmail(&user, email)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Change Email was failed ")
+		c.Abort()
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte("Changed Email"))
+}
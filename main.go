@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"time"
@@ -33,6 +34,15 @@ func main() {
 	crud.InitDB()
 	util.InitRedis()
 
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "recompute" {
+		recomputeBalances(os.Args[2:])
+		return
+	}
+
+	// Recurring Transactions Scheduler
+	go runRecurringScheduler()
+
 	// HTTP Endpoints Initilization
 	r := gin.Default()
 
@@ -100,15 +110,26 @@ func main() {
 		// Authentications
 		v1.GET("/user", endpoint.GetUser)
 		v1.POST("/user", endpoint.CreateUser)
+		v1.PATCH("/user", endpoint.UpdateUser)
+		v1.POST("/user/password", endpoint.ChangePassword)
+		v1.POST("/user/email", endpoint.ChangeEmail)
 		v1.POST("/login", endpoint.Login)
 		v1.GET("/logout", endpoint.Logout)
+		v1.POST("/token/refresh", endpoint.RefreshToken)
+		v1.GET("/session", endpoint.GetSessions)
+		v1.DELETE("/session", endpoint.DeleteAllSessions)
+		v1.DELETE("/session/:sid", endpoint.DeleteSession)
+		v1.POST("/password/forgot", endpoint.ForgotPassword)
+		v1.POST("/password/reset", endpoint.ResetPassword)
 
 		// Books
 		v1.GET("/book", endpoint.GetAllBooks)
 		v1.POST("/book", endpoint.CreateBook)
+		v1.GET("/bookTemplate", endpoint.GetBookTemplates)
 		v1.GET("/book/:bid", endpoint.GetBook)
 		v1.PATCH("/book/:bid", endpoint.UpdateBook)
 		v1.DELETE("/book/:bid", endpoint.DeleteBook)
+		v1.POST("/book/:bid/rollover", endpoint.RolloverBook)
 		v1.GET("/book/:bid/bookAuthorization", endpoint.GetBookAuthorizations)
 		v1.POST("/book/:bid/bookAuthorization", endpoint.CreateBookAuthorization)
 		v1.PATCH("/book/:bid/bookAuthorization/:uid", endpoint.UpdateBookAuthorization)
@@ -123,16 +144,56 @@ func main() {
 		// Transactions
 		v1.GET("/book/:bid/transaction", endpoint.GetTransactions)
 		v1.POST("/book/:bid/transaction", endpoint.CreateTransaction)
+		v1.POST("/book/:bid/transaction/batch", endpoint.CreateTransactions)
+		v1.POST("/book/:bid/transaction/post", endpoint.PostTransactions)
 		v1.GET("/book/:bid/transaction/:tid", endpoint.GetTransaction)
 		v1.PATCH("/book/:bid/transaction/:tid", endpoint.UpdateTransaction)
 		v1.DELETE("/book/:bid/transaction/:tid", endpoint.DeleteTransaction)
 		v1.GET("/book/:bid/transaction/page/:pid", endpoint.GetTransactionsWithPage)
 		v1.GET("/book/:bid/accountTitle/:tid/transactions", endpoint.GetSubTransactionsFromAccountTitle)
 		v1.GET("/book/:bid/accountTitle/:tid/transactions/:pid", endpoint.GetSubTransactionsFromAccountTitleWithPage)
+
+		// Import
+		v1.GET("/book/:bid/importProfile", endpoint.GetImportProfiles)
+		v1.POST("/book/:bid/importProfile", endpoint.CreateImportProfile)
+		v1.PATCH("/book/:bid/importProfile/:pid", endpoint.UpdateImportProfile)
+		v1.DELETE("/book/:bid/importProfile/:pid", endpoint.DeleteImportProfile)
+		v1.POST("/book/:bid/import/csv", endpoint.ImportStatementCSV)
+		v1.POST("/book/:bid/import/ofx", endpoint.ImportOFX)
+		v1.POST("/book/:bid/import/qif", endpoint.ImportQIF)
+
+		// Rules
+		v1.GET("/book/:bid/rule", endpoint.GetRules)
+		v1.POST("/book/:bid/rule", endpoint.CreateRule)
+		v1.GET("/book/:bid/rule/dryRun", endpoint.DryRunRules)
+		v1.PATCH("/book/:bid/rule/:rid", endpoint.UpdateRule)
+		v1.DELETE("/book/:bid/rule/:rid", endpoint.DeleteRule)
+
+		// Recurring Transactions
+		v1.GET("/book/:bid/recurring", endpoint.GetRecurringTransactions)
+		v1.POST("/book/:bid/recurring", endpoint.CreateRecurringTransaction)
+		v1.GET("/book/:bid/recurring/:rid", endpoint.GetRecurringTransaction)
+		v1.PATCH("/book/:bid/recurring/:rid", endpoint.UpdateRecurringTransaction)
+		v1.DELETE("/book/:bid/recurring/:rid", endpoint.DeleteRecurringTransaction)
+
+		// Reports
+		v1.GET("/book/:bid/report/trialBalance", endpoint.GetTrialBalance)
+		v1.GET("/book/:bid/report/balanceSheet", endpoint.GetBalanceSheet)
+		v1.GET("/book/:bid/report/profitAndLoss", endpoint.GetProfitAndLoss)
+		v1.GET("/book/:bid/accountTitle/:tid/ledger", endpoint.GetLedger)
+
+		// Export
+		v1.GET("/book/:bid/export/journal.csv", endpoint.ExportJournal)
+		v1.GET("/book/:bid/accountTitle/:tid/ledger.csv", endpoint.ExportLedger)
+
+		// Balance Check
+		v1.GET("/book/:bid/balanceCheck", endpoint.CheckBalances)
+		v1.POST("/book/:bid/balanceCheck", endpoint.RepairBalances)
 	}
 
 	// 本登録
 	r.GET("/createUser", endpoint.CreateUserAtDatabase)
+	r.GET("/changeEmail", endpoint.ConfirmEmailChange)
 
 	// Swgger
 	docs.SwaggerInfo.BasePath = "/api/v1"
@@ -142,3 +203,56 @@ func main() {
 	// Execution
 	r.Run(":" + os.Getenv("PORT"))
 }
+
+// Usage: main recompute [-repair] [book_id...]
+func recomputeBalances(args []string) {
+	flags := flag.NewFlagSet("recompute", flag.ExitOnError)
+	repair := flags.Bool("repair", false, "overwrite balances with the recomputed ones")
+	flags.Parse(args)
+
+	discrepancies, err := crud.CheckAllBalances(flags.Args(), *repair)
+	if err != nil {
+		fmt.Println("Balances could not checked: ", err)
+		os.Exit(1)
+	}
+
+	for _, discrepancy := range discrepancies {
+		fmt.Printf("%s\t%d\t%s\t%s\tstored=%d\texpected=%d\tdifference=%d\n",
+			discrepancy.BookId, discrepancy.AccountTitleId, discrepancy.Code, discrepancy.Name,
+			discrepancy.Amount, discrepancy.Expected, discrepancy.Difference)
+	}
+
+	if *repair {
+		fmt.Printf("%d balances repaired\n", len(discrepancies))
+	} else {
+		fmt.Printf("%d discrepancies found\n", len(discrepancies))
+		if len(discrepancies) > 0 {
+			os.Exit(1)
+		}
+	}
+}
+
+// Creates the due recurring transactions every RECURRING_INTERVAL (e.g. 30s, default 1m)
+func runRecurringScheduler() {
+	interval := time.Minute
+	if value := os.Getenv("RECURRING_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			fmt.Println("RECURRING_INTERVAL is invalid: ", value)
+		} else {
+			interval = parsed
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		created, err := crud.RunRecurringTransactions(time.Now())
+		if err != nil {
+			fmt.Println("Recurring Transactions could not created: ", err)
+		} else if created > 0 {
+			fmt.Printf("%d recurring transactions created\n", created)
+		}
+		<-ticker.C
+	}
+}
@@ -4,16 +4,21 @@ import (
 	"time"
 )
 
+// PreviousBookId is the book of the previous year which was rolled over into this book.
 type Book struct {
-	BookId             string              `gorm:"default:uuid_generate_v4();primaryKey;not null;unique" json:"book_id"`
-	Name               string              `gorm:"not null" json:"name"`
-	Year               uint                `gorm:"not null" json:"year"`
-	BookAuthorizations []BookAuthorization `gorm:"foreignKey:BookId;references:BookId;constraint:OnDelete:CASCADE;" json:"-"`
-	AccountTitles      []AccountTitle      `gorm:"foreignKey:BookId;references:BookId;constraint:OnDelete:CASCADE;" json:"-"`
-	Transactions       []Transaction       `gorm:"foreignKey:BookId;references:BookId;constraint:OnDelete:CASCADE;" json:"-"`
-	SubTransactions    []SubTransaction    `gorm:"foreignKey:BookId;references:BookId;constraint:OnDelete:CASCADE;" json:"-"`
-	CreatedAt          time.Time           `gorm:"index" json:"created_at"`
-	UpdatedAt          time.Time           `json:"updated_at"`
+	BookId                string                 `gorm:"default:uuid_generate_v4();primaryKey;not null;unique" json:"book_id"`
+	Name                  string                 `gorm:"not null" json:"name"`
+	Year                  uint                   `gorm:"not null" json:"year"`
+	BookAuthorizations    []BookAuthorization    `gorm:"foreignKey:BookId;references:BookId;constraint:OnDelete:CASCADE;" json:"-"`
+	AccountTitles         []AccountTitle         `gorm:"foreignKey:BookId;references:BookId;constraint:OnDelete:CASCADE;" json:"-"`
+	Transactions          []Transaction          `gorm:"foreignKey:BookId;references:BookId;constraint:OnDelete:CASCADE;" json:"-"`
+	SubTransactions       []SubTransaction       `gorm:"foreignKey:BookId;references:BookId;constraint:OnDelete:CASCADE;" json:"-"`
+	ImportProfiles        []ImportProfile        `gorm:"foreignKey:BookId;references:BookId;constraint:OnDelete:CASCADE;" json:"-"`
+	Rules                 []Rule                 `gorm:"foreignKey:BookId;references:BookId;constraint:OnDelete:CASCADE;" json:"-"`
+	RecurringTransactions []RecurringTransaction `gorm:"foreignKey:BookId;references:BookId;constraint:OnDelete:CASCADE;" json:"-"`
+	PreviousBookId        *string                `gorm:"uniqueIndex" json:"previous_book_id"`
+	CreatedAt             time.Time              `gorm:"index" json:"created_at"`
+	UpdatedAt             time.Time              `json:"updated_at"`
 }
 
 type BookAuthorization struct {
@@ -26,6 +31,8 @@ type BookAuthorization struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// ClosesAtYearEnd is for the equity accounts closed into the retained earnings at year end (e.g. 事業主貸, 事業主借).
+// Revenue and expense accounts are always closed.
 type AccountTitle struct {
 	AccountTitleId  uint64           `gorm:"primaryKey;not null;autoIncrement" json:"title_id"`
 	BookId          string           `gorm:"primaryKey;not null" json:"book_id"`
@@ -33,19 +40,37 @@ type AccountTitle struct {
 	Amount          int64            `gorm:"not null" json:"amount"`
 	AmountBase      int64            `gorm:"not null;default:0" json:"amount_base"`
 	SubTransactions []SubTransaction `gorm:"foreignKey:AccountTitleId,BookId;references:AccountTitleId,BookId;constraint:OnDelete:CASCADE;" json:"sub_transactions"`
-	Type            uint             `gorm:"not null" json:"type"`
+	Category        AccountCategory  `gorm:"not null;default:''" json:"category"`
+	IsContra        bool             `gorm:"not null;default:false" json:"is_contra"`
+	NormalBalance   BalanceSide      `gorm:"not null;default:''" json:"normal_balance"`
+	ParentId        *uint64          `json:"parent_id"`
+	Code            string           `gorm:"not null;default:''" json:"code"`
+	SortOrder       int              `gorm:"not null;default:0" json:"sort_order"`
+	ClosesAtYearEnd bool             `gorm:"not null;default:false" json:"closes_at_year_end"`
 	CreatedAt       time.Time        `gorm:"index" json:"created_at"`
 	UpdatedAt       time.Time        `json:"updated_at"`
 }
 
+// Account title with its sub accounts.
+// RolledUpAmount is the balance including the sub accounts.
+type AccountTitleNode struct {
+	AccountTitle
+	RolledUpAmount int64              `json:"rolled_up_amount"`
+	Children       []AccountTitleNode `json:"children"`
+}
+
+// ExternalId is the ID of the imported statement line, unique in the book if not empty.
+// Draft transactions do not change the balances of the account titles until they are posted.
 type Transaction struct {
-	TransactionId   uint64           `gorm:"index;primaryKey;not null;autoIncrement" json:"transaction_id"`
-	BookId          string           `gorm:"primaryKey;not null" json:"book_id"`
-	Description     string           `gorm:"not null" json:"description"`
-	SubTransactions []SubTransaction `gorm:"foreignKey:TransactionId,BookId;references:TransactionId,BookId;constraint:OnDelete:CASCADE;" json:"sub_transactions"`
-	OccurredAt      time.Time        `gorm:"index" json:"occurred_at"`
-	CreatedAt       time.Time        `json:"created_at"`
-	UpdatedAt       time.Time        `json:"updated_at"`
+	TransactionId   uint64            `gorm:"index;primaryKey;not null;autoIncrement" json:"transaction_id"`
+	BookId          string            `gorm:"primaryKey;not null;uniqueIndex:idx_transactions_external_id" json:"book_id"`
+	Description     string            `gorm:"not null" json:"description"`
+	SubTransactions []SubTransaction  `gorm:"foreignKey:TransactionId,BookId;references:TransactionId,BookId;constraint:OnDelete:CASCADE;" json:"sub_transactions"`
+	OccurredAt      time.Time         `gorm:"index" json:"occurred_at"`
+	ExternalId      string            `gorm:"not null;default:'';uniqueIndex:idx_transactions_external_id,where:external_id <> ''" json:"external_id"`
+	Status          TransactionStatus `gorm:"not null;default:'posted';index" json:"status"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
 }
 
 type SubTransaction struct {
@@ -60,3 +85,54 @@ type SubTransaction struct {
 	CreatedAt        time.Time     `gorm:"index" json:"created_at"`
 	UpdatedAt        time.Time     `json:"updated_at"`
 }
+
+type TransactionStatus string
+
+const (
+	TransactionStatusPosted TransactionStatus = "posted"
+	TransactionStatusDraft  TransactionStatus = "draft"
+)
+
+func (status TransactionStatus) IsValid() bool {
+	return status == TransactionStatusPosted || status == TransactionStatusDraft
+}
+
+type AccountCategory string
+
+const (
+	AccountCategoryAsset     AccountCategory = "asset"     // 資産
+	AccountCategoryLiability AccountCategory = "liability" // 負債
+	AccountCategoryEquity    AccountCategory = "equity"    // 純資産
+	AccountCategoryRevenue   AccountCategory = "revenue"   // 収益
+	AccountCategoryExpense   AccountCategory = "expense"   // 費用
+)
+
+type BalanceSide string
+
+const (
+	BalanceSideDebit  BalanceSide = "debit"
+	BalanceSideCredit BalanceSide = "credit"
+)
+
+func (category AccountCategory) IsValid() bool {
+	switch category {
+	case AccountCategoryAsset, AccountCategoryLiability, AccountCategoryEquity,
+		AccountCategoryRevenue, AccountCategoryExpense:
+		return true
+	}
+	return false
+}
+
+// Returns the side on which the balance of the account increases.
+// Contra accounts (e.g. 減価償却累計額, 事業主貸) are on the opposite side of their category.
+func NormalBalanceOf(category AccountCategory, isContra bool) BalanceSide {
+	debit := category == AccountCategoryAsset || category == AccountCategoryExpense
+	if isContra {
+		debit = !debit
+	}
+
+	if debit {
+		return BalanceSideDebit
+	}
+	return BalanceSideCredit
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+)
+
+// Column mapping of a bank or credit card statement CSV.
+// Columns are 0-based and -1 means the column is not in the CSV.
+// AmountColumn is the column of the signed amount (deposits are positive), used instead of the withdrawal and the deposit columns.
+type ImportProfile struct {
+	ImportProfileId   uint64    `gorm:"primaryKey;not null;autoIncrement" json:"import_profile_id"`
+	BookId            string    `gorm:"primaryKey;not null" json:"book_id"`
+	Name              string    `gorm:"not null" json:"name"`
+	Encoding          string    `gorm:"not null;default:'shift_jis'" json:"encoding"`
+	SkipRows          int       `gorm:"not null;default:0" json:"skip_rows"`
+	DateColumn        int       `gorm:"not null" json:"date_column"`
+	DateFormat        string    `gorm:"not null;default:''" json:"date_format"`
+	DescriptionColumn int       `gorm:"not null" json:"description_column"`
+	WithdrawalColumn  int       `gorm:"not null" json:"withdrawal_column"`
+	DepositColumn     int       `gorm:"not null" json:"deposit_column"`
+	AmountColumn      int       `gorm:"not null;default:-1" json:"amount_column"`
+	CreatedAt         time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Line of a statement.
+// Amount is positive for a deposit and negative for a withdrawal.
+// ExternalId identifies the line across imports of overlapping statements.
+type StatementLine struct {
+	Row         int       `json:"row"`
+	OccurredAt  time.Time `json:"occurred_at"`
+	Description string    `json:"description"`
+	Amount      int64     `json:"amount"`
+	ExternalId  string    `json:"external_id"`
+}
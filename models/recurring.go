@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+)
+
+// Transaction which is created on the dates of the RRULE like FREQ=MONTHLY;BYMONTHDAY=27.
+// NextOccurrence is nil when the schedule has no more occurrences.
+// LastError is the reason why the scheduler disabled the schedule.
+type RecurringTransaction struct {
+	RecurringTransactionId uint64                    `gorm:"primaryKey;not null;autoIncrement" json:"recurring_transaction_id"`
+	BookId                 string                    `gorm:"primaryKey;not null" json:"book_id"`
+	Description            string                    `gorm:"not null" json:"description"`
+	Rule                   string                    `gorm:"not null" json:"rule"`
+	StartsAt               time.Time                 `gorm:"not null" json:"starts_at"`
+	AsDraft                bool                      `gorm:"not null;default:false" json:"as_draft"`
+	IsEnabled              bool                      `gorm:"not null;default:true" json:"is_enabled"`
+	SubTransactions        []RecurringSubTransaction `gorm:"foreignKey:RecurringTransactionId,BookId;references:RecurringTransactionId,BookId;constraint:OnDelete:CASCADE;" json:"sub_transactions"`
+	NextOccurrence         *time.Time                `gorm:"index" json:"next_occurrence"`
+	LastOccurrence         *time.Time                `json:"last_occurrence"`
+	LastError              string                    `gorm:"not null;default:''" json:"last_error"`
+	CreatedAt              time.Time                 `gorm:"index" json:"created_at"`
+	UpdatedAt              time.Time                 `json:"updated_at"`
+}
+
+type RecurringSubTransaction struct {
+	RecurringSubTransactionId uint64    `gorm:"primaryKey;not null;autoIncrement" json:"recurring_sub_transaction_id"`
+	BookId                    string    `gorm:"primaryKey;not null" json:"-"`
+	RecurringTransactionId    uint64    `gorm:"primaryKey;not null" json:"-"`
+	IsDebit                   bool      `gorm:"not null" json:"is_debit"`
+	AccountTitleId            uint64    `gorm:"not null" json:"account_title_id"`
+	Amount                    int64     `gorm:"not null" json:"amount"`
+	CreatedAt                 time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt                 time.Time `json:"updated_at"`
+}
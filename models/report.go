@@ -0,0 +1,120 @@
+package model
+
+import (
+	"time"
+)
+
+type TrialBalanceRow struct {
+	AccountTitleId uint64          `json:"title_id"`
+	Code           string          `json:"code"`
+	Name           string          `json:"name"`
+	Category       AccountCategory `json:"category"`
+	OpeningBalance int64           `json:"opening_balance"`
+	Debit          int64           `json:"debit"`
+	Credit         int64           `json:"credit"`
+	ClosingBalance int64           `json:"closing_balance"`
+}
+
+type TrialBalance struct {
+	BookId string            `json:"book_id"`
+	From   time.Time         `json:"from"`
+	To     time.Time         `json:"to"`
+	Rows   []TrialBalanceRow `json:"rows"`
+	// Period Totals
+	TotalDebit  int64 `json:"total_debit"`
+	TotalCredit int64 `json:"total_credit"`
+	// Balances by Side
+	OpeningDebitBalance  int64 `json:"opening_debit_balance"`
+	OpeningCreditBalance int64 `json:"opening_credit_balance"`
+	ClosingDebitBalance  int64 `json:"closing_debit_balance"`
+	ClosingCreditBalance int64 `json:"closing_credit_balance"`
+	Balanced             bool  `json:"balanced"`
+}
+
+type StatementRow struct {
+	AccountTitleId uint64          `json:"title_id"`
+	Code           string          `json:"code"`
+	Name           string          `json:"name"`
+	Category       AccountCategory `json:"category"`
+	Amount         int64           `json:"amount"`
+}
+
+type BalanceSheet struct {
+	BookId                    string         `json:"book_id"`
+	To                        time.Time      `json:"to"`
+	Assets                    []StatementRow `json:"assets"`
+	Liabilities               []StatementRow `json:"liabilities"`
+	Equity                    []StatementRow `json:"equity"`
+	TotalAssets               int64          `json:"total_assets"`
+	TotalLiabilities          int64          `json:"total_liabilities"`
+	TotalEquity               int64          `json:"total_equity"`
+	NetIncome                 int64          `json:"net_income"`
+	TotalLiabilitiesAndEquity int64          `json:"total_liabilities_and_equity"`
+	Balanced                  bool           `json:"balanced"`
+}
+
+type ProfitAndLoss struct {
+	BookId       string         `json:"book_id"`
+	From         time.Time      `json:"from"`
+	To           time.Time      `json:"to"`
+	Revenues     []StatementRow `json:"revenues"`
+	Expenses     []StatementRow `json:"expenses"`
+	TotalRevenue int64          `json:"total_revenue"`
+	TotalExpense int64          `json:"total_expense"`
+	NetIncome    int64          `json:"net_income"`
+}
+
+type LedgerCounterAccount struct {
+	AccountTitleId uint64 `json:"title_id"`
+	Code           string `json:"code"`
+	Name           string `json:"name"`
+	Amount         int64  `json:"amount"`
+}
+
+// Entry of the general ledger (総勘定元帳).
+// Balance is the running balance after the entry.
+type LedgerEntry struct {
+	SubTransactionId uint64                 `json:"sub_transaction_id"`
+	TransactionId    uint64                 `json:"transaction_id"`
+	OccurredAt       time.Time              `json:"occurred_at"`
+	Description      string                 `json:"description"`
+	CounterAccounts  []LedgerCounterAccount `json:"counter_accounts"`
+	Debit            int64                  `json:"debit"`
+	Credit           int64                  `json:"credit"`
+	Balance          int64                  `json:"balance"`
+}
+
+type Ledger struct {
+	BookId         string        `json:"book_id"`
+	AccountTitle   AccountTitle  `json:"account_title"`
+	From           time.Time     `json:"from"`
+	To             time.Time     `json:"to"`
+	OpeningBalance int64         `json:"opening_balance"`
+	Entries        []LedgerEntry `json:"entries"`
+	TotalDebit     int64         `json:"total_debit"`
+	TotalCredit    int64         `json:"total_credit"`
+	ClosingBalance int64         `json:"closing_balance"`
+}
+
+// Amount is the stored balance and Expected is the balance recomputed from the sub transactions
+type BalanceDiscrepancy struct {
+	BookId         string `json:"book_id"`
+	AccountTitleId uint64 `json:"title_id"`
+	Code           string `json:"code"`
+	Name           string `json:"name"`
+	Amount         int64  `json:"amount"`
+	Expected       int64  `json:"expected"`
+	Difference     int64  `json:"difference"`
+}
+
+// Row of the journal (仕訳帳).
+// A transaction with several lines spans several rows.
+type JournalRow struct {
+	OccurredAt    time.Time `json:"occurred_at"`
+	TransactionId uint64    `json:"transaction_id"`
+	Description   string    `json:"description"`
+	DebitTitle    string    `json:"debit_title"`
+	DebitAmount   int64     `json:"debit_amount"`
+	CreditTitle   string    `json:"credit_title"`
+	CreditAmount  int64     `json:"credit_amount"`
+}
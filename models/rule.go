@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+)
+
+// Rule to complete a transaction which has only the line of the source account (bank, credit card, ...).
+// Empty conditions match everything. The amount is compared without the sign.
+// DescriptionRewrite can refer to the groups of DescriptionPattern like $1.
+type Rule struct {
+	RuleId                uint64    `gorm:"primaryKey;not null;autoIncrement" json:"rule_id"`
+	BookId                string    `gorm:"primaryKey;not null" json:"book_id"`
+	Name                  string    `gorm:"not null" json:"name"`
+	Priority              int       `gorm:"not null;default:0" json:"priority"`
+	IsEnabled             bool      `gorm:"not null;default:true" json:"is_enabled"`
+	DescriptionContains   string    `gorm:"not null;default:''" json:"description_contains"`
+	DescriptionPattern    string    `gorm:"not null;default:''" json:"description_pattern"`
+	MinAmount             *int64    `json:"min_amount"`
+	MaxAmount             *int64    `json:"max_amount"`
+	SourceAccountTitleId  *uint64   `json:"source_account_title_id"`
+	CounterAccountTitleId uint64    `gorm:"not null" json:"counter_account_title_id"`
+	DescriptionRewrite    string    `gorm:"not null;default:''" json:"description_rewrite"`
+	CreatedAt             time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+// Transaction which the rule matches.
+// Description is the description after the rewrite.
+type RuleMatch struct {
+	RuleId                 uint64      `json:"rule_id"`
+	Transaction            Transaction `json:"transaction"`
+	SourceSubTransactionId uint64      `json:"source_sub_transaction_id"`
+	CounterAccountTitleId  uint64      `json:"counter_account_title_id"`
+	Description            string      `json:"description"`
+}
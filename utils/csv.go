@@ -0,0 +1,97 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+)
+
+const (
+	CSVEncodingShiftJIS = "shift_jis"
+	CSVEncodingUTF8     = "utf-8"
+)
+
+var InvalidCSVEncodingError = errors.New("Invalid CSV Encoding")
+
+// CSV writer which Excel can open.
+// Close must be called to flush the rest of the rows.
+type CSVWriter struct {
+	*csv.Writer
+	closer io.Closer
+}
+
+// Shift_JIS replaces the characters which can not be encoded, and UTF-8 starts with BOM
+func NewCSVWriter(w io.Writer, encodingName string) (*CSVWriter, error) {
+	var closer io.Closer
+
+	switch encodingName {
+	case CSVEncodingShiftJIS:
+		encoder := encoding.ReplaceUnsupported(japanese.ShiftJIS.NewEncoder())
+		transformer := transform.NewWriter(w, encoder)
+		w = transformer
+		closer = transformer
+	case CSVEncodingUTF8:
+		if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, InvalidCSVEncodingError
+	}
+
+	writer := csv.NewWriter(w)
+	writer.UseCRLF = true
+
+	return &CSVWriter{Writer: writer, closer: closer}, nil
+}
+
+func (w *CSVWriter) Close() error {
+	w.Writer.Flush()
+	if err := w.Writer.Error(); err != nil {
+		return err
+	}
+	if w.closer != nil {
+		return w.closer.Close()
+	}
+	return nil
+}
+
+func IsValidCSVEncoding(encodingName string) bool {
+	return encodingName == CSVEncodingShiftJIS || encodingName == CSVEncodingUTF8
+}
+
+// Decodes Shift_JIS or UTF-8 with or without BOM into UTF-8
+func NewTextReader(r io.Reader, encodingName string) (io.Reader, error) {
+	switch encodingName {
+	case CSVEncodingShiftJIS:
+		return transform.NewReader(r, japanese.ShiftJIS.NewDecoder()), nil
+	case CSVEncodingUTF8:
+		buffered := bufio.NewReader(r)
+		if bom, err := buffered.Peek(3); err == nil && bytes.Equal(bom, []byte("\xEF\xBB\xBF")) {
+			buffered.Discard(3)
+		}
+		return buffered, nil
+	default:
+		return nil, InvalidCSVEncodingError
+	}
+}
+
+// CSV reader of Shift_JIS or UTF-8 with or without BOM.
+// Rows may have different numbers of fields.
+func NewCSVReader(r io.Reader, encodingName string) (*csv.Reader, error) {
+	r, err := NewTextReader(r, encodingName)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	return reader, nil
+}
@@ -0,0 +1,105 @@
+package util
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+)
+
+var ofxTagPattern = regexp.MustCompile(`<(/?)([A-Za-z0-9.]+)>([^<]*)`)
+
+// Reads the transactions (STMTTRN) of OFX 1.x (SGML) or 2.x (XML).
+// Lines with FITID are identified by the account ID and the FITID.
+func ParseOFX(r io.Reader, encodingName string) ([]model.StatementLine, error) {
+	r, err := NewTextReader(r, encodingName)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	body := data
+	if start := bytes.Index(bytes.ToUpper(data), []byte("<OFX>")); start >= 0 {
+		body = data[start:]
+	} else {
+		return nil, &StatementParseError{Row: 1, Message: "OFX element was not found"}
+	}
+
+	lines := []model.StatementLine{}
+	var accountId string
+	var fields map[string]string
+	for _, match := range ofxTagPattern.FindAllSubmatch(body, -1) {
+		isClosing := len(match[1]) > 0
+		tag := strings.ToUpper(string(match[2]))
+		value := html.UnescapeString(strings.TrimSpace(string(match[3])))
+
+		switch {
+		case tag == "STMTTRN" && !isClosing:
+			fields = map[string]string{}
+		case tag == "STMTTRN" && isClosing:
+			if fields == nil {
+				continue
+			}
+			line, err := ofxStatementLineOf(fields, accountId, len(lines)+1)
+			if err != nil {
+				return nil, err
+			}
+			if line.Amount != 0 {
+				lines = append(lines, line)
+			}
+			fields = nil
+		case tag == "ACCTID" && !isClosing:
+			accountId = value
+		case fields != nil && !isClosing:
+			fields[tag] = value
+		}
+	}
+
+	assignStatementExternalIds(lines)
+
+	return lines, nil
+}
+
+func ofxStatementLineOf(fields map[string]string, accountId string, row int) (model.StatementLine, error) {
+	occurredAt, err := parseOFXDate(fields["DTPOSTED"])
+	if err != nil {
+		return model.StatementLine{}, &StatementParseError{Row: row, Message: "DTPOSTED is invalid: " + err.Error()}
+	}
+
+	amount, err := parseStatementAmount(fields["TRNAMT"])
+	if err != nil {
+		return model.StatementLine{}, &StatementParseError{Row: row, Message: "TRNAMT is invalid: " + err.Error()}
+	}
+
+	description := fields["NAME"]
+	if description == "" {
+		description = fields["MEMO"]
+	}
+
+	line := model.StatementLine{
+		Row:         row,
+		OccurredAt:  occurredAt,
+		Description: description,
+		Amount:      amount,
+	}
+	if fitId := fields["FITID"]; fitId != "" {
+		line.ExternalId = "fitid:" + accountId + ":" + fitId
+	}
+
+	return line, nil
+}
+
+// Only the date of YYYYMMDD[HHMMSS[.XXX]][gmt offset] is used
+func parseOFXDate(value string) (time.Time, error) {
+	if len(value) > 8 {
+		value = value[:8]
+	}
+	return time.ParseInLocation("20060102", value, time.Local)
+}
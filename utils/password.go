@@ -0,0 +1,164 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+var InvalidPasswordHashError = errors.New("Invalid Password Hash")
+
+// Hashes and verifies the passwords of a format
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// False if the hash is not of this format
+	Owns(hash string) bool
+	Verify(password string, hash string) (bool, error)
+	// True if the hash should be replaced by Hash, e.g. with weaker parameters
+	NeedsRehash(hash string) bool
+}
+
+// Argon2id with a salt per password, encoded like $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Parameters recommended by RFC 9106 for the memory constrained environments
+var DefaultPasswordHasher PasswordHasher = &Argon2idHasher{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Verified on login and then replaced by DefaultPasswordHasher
+var legacyPasswordHashers = []PasswordHasher{&legacySHA256Hasher{}}
+
+func (hasher *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, hasher.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, hasher.Iterations, hasher.Memory, hasher.Parallelism, hasher.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		hasher.Memory, hasher.Iterations, hasher.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (hasher *Argon2idHasher) Owns(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (hasher *Argon2idHasher) Verify(password string, hash string) (bool, error) {
+	params, salt, key, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(actual, key) == 1, nil
+}
+
+func (hasher *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, salt, key, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+	return params.Memory != hasher.Memory || params.Iterations != hasher.Iterations || params.Parallelism != hasher.Parallelism ||
+		uint32(len(salt)) != hasher.SaltLength || uint32(len(key)) != hasher.KeyLength
+}
+
+func decodeArgon2idHash(hash string) (Argon2idHasher, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2idHasher{}, nil, nil, InvalidPasswordHashError
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2idHasher{}, nil, nil, InvalidPasswordHashError
+	}
+
+	var params Argon2idHasher
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return Argon2idHasher{}, nil, nil, InvalidPasswordHashError
+	}
+	if params.Iterations == 0 || params.Parallelism == 0 {
+		return Argon2idHasher{}, nil, nil, InvalidPasswordHashError
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2idHasher{}, nil, nil, InvalidPasswordHashError
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Argon2idHasher{}, nil, nil, InvalidPasswordHashError
+	}
+
+	return params, salt, key, nil
+}
+
+// sha256(password + HASH_SALT) in hex of the older versions
+type legacySHA256Hasher struct{}
+
+func (hasher *legacySHA256Hasher) Hash(password string) (string, error) {
+	r := sha256.Sum256([]byte(password + os.Getenv("HASH_SALT")))
+	return hex.EncodeToString(r[:]), nil
+}
+
+func (hasher *legacySHA256Hasher) Owns(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+func (hasher *legacySHA256Hasher) Verify(password string, hash string) (bool, error) {
+	actual, _ := hasher.Hash(password)
+	return subtle.ConstantTimeCompare([]byte(actual), []byte(hash)) == 1, nil
+}
+
+func (hasher *legacySHA256Hasher) NeedsRehash(hash string) bool {
+	return true
+}
+
+func HashPassword(password string) (string, error) {
+	return DefaultPasswordHasher.Hash(password)
+}
+
+// Verifies the password with the hasher of the hash.
+// needsRehash is true if the password is correct and the hash should be replaced by HashPassword.
+func VerifyPassword(password string, hash string) (ok bool, needsRehash bool, err error) {
+	hashers := append([]PasswordHasher{DefaultPasswordHasher}, legacyPasswordHashers...)
+	for _, hasher := range hashers {
+		if !hasher.Owns(hash) {
+			continue
+		}
+
+		ok, err := hasher.Verify(password, hash)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		return true, hasher != DefaultPasswordHasher || hasher.NeedsRehash(hash), nil
+	}
+
+	return false, false, InvalidPasswordHashError
+}
@@ -0,0 +1,81 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestArgon2idPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, needsRehash, err := VerifyPassword("correct horse", hash)
+	if err != nil || !ok || needsRehash {
+		t.Errorf("correct password: ok = %v, needsRehash = %v, err = %v", ok, needsRehash, err)
+	}
+
+	ok, needsRehash, err = VerifyPassword("wrong horse", hash)
+	if err != nil || ok || needsRehash {
+		t.Errorf("wrong password: ok = %v, needsRehash = %v, err = %v", ok, needsRehash, err)
+	}
+
+	other, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == hash {
+		t.Error("hashes of the same password have the same salt")
+	}
+}
+
+// Users of the older versions can log in and their hashes are replaced
+func TestLegacySHA256Password(t *testing.T) {
+	t.Setenv("HASH_SALT", "pepper")
+	sum := sha256.Sum256([]byte("correct horse" + "pepper"))
+	hash := hex.EncodeToString(sum[:])
+
+	ok, needsRehash, err := VerifyPassword("correct horse", hash)
+	if err != nil || !ok || !needsRehash {
+		t.Errorf("correct password: ok = %v, needsRehash = %v, err = %v", ok, needsRehash, err)
+	}
+
+	ok, needsRehash, err = VerifyPassword("wrong horse", hash)
+	if err != nil || ok || needsRehash {
+		t.Errorf("wrong password: ok = %v, needsRehash = %v, err = %v", ok, needsRehash, err)
+	}
+}
+
+func TestArgon2idPasswordWithOtherParameters(t *testing.T) {
+	weaker := &Argon2idHasher{Memory: 8 * 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	hash, err := weaker.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, needsRehash, err := VerifyPassword("correct horse", hash)
+	if err != nil || !ok || !needsRehash {
+		t.Errorf("ok = %v, needsRehash = %v, err = %v", ok, needsRehash, err)
+	}
+}
+
+func TestMalformedPasswordHash(t *testing.T) {
+	hashes := []string{
+		"",
+		"plain password",
+		"$argon2id$v=19$m=65536,t=3,p=2$c2FsdA",
+		"$argon2id$v=18$m=65536,t=3,p=2$c2FsdHNhbHRzYWx0$a2V5",
+		"$argon2id$v=19$m=65536,t=0,p=2$c2FsdHNhbHRzYWx0$a2V5",
+		"$argon2id$v=19$m=65536,t=3,p=2$!!!$a2V5",
+		"$argon2id$v=19$m=65536,t=3,p=2$c2FsdHNhbHRzYWx0$",
+	}
+
+	for _, hash := range hashes {
+		ok, needsRehash, err := VerifyPassword("correct horse", hash)
+		if err != InvalidPasswordHashError || ok || needsRehash {
+			t.Errorf("%q: ok = %v, needsRehash = %v, err = %v", hash, ok, needsRehash, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package util
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+)
+
+var qifDateFormats = []string{"2006/1/2", "2006-1-2", "1/2/2006", "1/2/06"}
+
+// Reads the transactions of QIF.
+// QIF has no ID of the bank, so the lines are identified by the hash.
+func ParseQIF(r io.Reader, encodingName string) ([]model.StatementLine, error) {
+	r, err := NewTextReader(r, encodingName)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []model.StatementLine{}
+	scanner := bufio.NewScanner(r)
+	row := 0
+	recordRow := 0
+	fields := map[byte]string{}
+	for scanner.Scan() {
+		row++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "!") {
+			continue
+		}
+		if recordRow == 0 {
+			recordRow = row
+		}
+
+		if text[0] != '^' {
+			// Repeated fields like the address lines (A) keep the first value
+			if _, ok := fields[text[0]]; !ok {
+				fields[text[0]] = strings.TrimSpace(text[1:])
+			}
+			continue
+		}
+
+		line, err := qifStatementLineOf(fields, recordRow)
+		if err != nil {
+			return nil, err
+		}
+		if line.Amount != 0 {
+			lines = append(lines, line)
+		}
+		fields = map[byte]string{}
+		recordRow = 0
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	assignStatementExternalIds(lines)
+
+	return lines, nil
+}
+
+func qifStatementLineOf(fields map[byte]string, row int) (model.StatementLine, error) {
+	occurredAt, err := parseQIFDate(fields['D'])
+	if err != nil {
+		return model.StatementLine{}, &StatementParseError{Row: row, Message: "date is invalid: " + err.Error()}
+	}
+
+	amountField, ok := fields['T']
+	if !ok {
+		amountField = fields['U']
+	}
+	amount, err := parseStatementAmount(amountField)
+	if err != nil {
+		return model.StatementLine{}, &StatementParseError{Row: row, Message: "amount is invalid: " + err.Error()}
+	}
+
+	description := fields['P']
+	if description == "" {
+		description = fields['M']
+	}
+
+	return model.StatementLine{
+		Row:         row,
+		OccurredAt:  occurredAt,
+		Description: description,
+		Amount:      amount,
+	}, nil
+}
+
+// Accepts the dates like 2024/1/2, 1/2/2024 and 1/2'24
+func parseQIFDate(value string) (time.Time, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), "'", "/")
+
+	var err error
+	for _, format := range qifDateFormats {
+		var date time.Time
+		date, err = time.ParseInLocation(format, value, time.Local)
+		if err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, err
+}
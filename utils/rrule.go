@@ -0,0 +1,345 @@
+package util
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Subset of RRULE (RFC 5545) for recurring transactions.
+// FREQ is DAILY, WEEKLY, MONTHLY or YEARLY, with INTERVAL, COUNT, UNTIL,
+// BYDAY (WEEKLY only, without the numbers like 2MO), BYMONTHDAY (negative from the end of the month) and BYMONTH.
+// Occurrences are dates and the days which do not exist in the month are skipped.
+type RRule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []time.Weekday
+	ByMonthDay []int
+	ByMonth    []time.Month
+}
+
+const (
+	RRuleFreqDaily   = "DAILY"
+	RRuleFreqWeekly  = "WEEKLY"
+	RRuleFreqMonthly = "MONTHLY"
+	RRuleFreqYearly  = "YEARLY"
+)
+
+// The calendar repeats every 400 years (4800 months), so a rule without occurrences
+// in this many periods in a row never occurs, like BYMONTH=2;BYMONTHDAY=30
+const rruleCalendarCycle = 4800
+
+var InvalidRRuleError = errors.New("Invalid RRULE")
+var NoRRuleOccurrenceError = errors.New("RRULE never occurs")
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Parses the rule like FREQ=MONTHLY;BYMONTHDAY=27 with or without the RRULE: prefix
+func ParseRRule(value string) (*RRule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	rule := RRule{Interval: 1}
+
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, found := strings.Cut(part, "=")
+		if !found || val == "" {
+			return nil, InvalidRRuleError
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(val)
+			switch rule.Freq {
+			case RRuleFreqDaily, RRuleFreqWeekly, RRuleFreqMonthly, RRuleFreqYearly:
+			default:
+				return nil, InvalidRRuleError
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return nil, InvalidRRuleError
+			}
+			rule.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count < 1 {
+				return nil, InvalidRRuleError
+			}
+			rule.Count = count
+		case "UNTIL":
+			if len(val) < 8 {
+				return nil, InvalidRRuleError
+			}
+			until, err := time.ParseInLocation("20060102", val[:8], time.Local)
+			if err != nil {
+				return nil, InvalidRRuleError
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				weekday, ok := rruleWeekdays[strings.ToUpper(day)]
+				if !ok {
+					return nil, InvalidRRuleError
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(val, ",") {
+				monthDay, err := strconv.Atoi(day)
+				if err != nil || monthDay == 0 || monthDay < -31 || monthDay > 31 {
+					return nil, InvalidRRuleError
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, monthDay)
+			}
+		case "BYMONTH":
+			for _, month := range strings.Split(val, ",") {
+				m, err := strconv.Atoi(month)
+				if err != nil || m < 1 || m > 12 {
+					return nil, InvalidRRuleError
+				}
+				rule.ByMonth = append(rule.ByMonth, time.Month(m))
+			}
+		default:
+			return nil, InvalidRRuleError
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, InvalidRRuleError
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != RRuleFreqWeekly {
+		return nil, InvalidRRuleError
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq != RRuleFreqMonthly && rule.Freq != RRuleFreqYearly {
+		return nil, InvalidRRuleError
+	}
+	if len(rule.ByMonth) > 0 && rule.Freq != RRuleFreqYearly {
+		return nil, InvalidRRuleError
+	}
+
+	return &rule, nil
+}
+
+// Returns the first occurrence after the time, counting the occurrences from dtstart.
+// It starts from the period of the time, and the occurrences of the periods before it are counted only for COUNT.
+// False means the rule has no more occurrences, and NoRRuleOccurrenceError means it has none at all.
+func (rule *RRule) Next(dtstart time.Time, after time.Time) (time.Time, bool, error) {
+	dtstart = time.Date(dtstart.Year(), dtstart.Month(), dtstart.Day(), 0, 0, 0, 0, dtstart.Location())
+
+	period := rule.periodOf(dtstart, after)
+	count := 0
+	if rule.Count > 0 {
+		count = rule.countBefore(dtstart, period)
+		if count >= rule.Count {
+			return time.Time{}, false, nil
+		}
+	}
+
+	for emptyPeriods := 0; emptyPeriods < rruleCalendarCycle; period++ {
+		occurrences := rule.occurrencesInPeriod(dtstart, period)
+		if len(occurrences) == 0 {
+			emptyPeriods++
+			continue
+		}
+		emptyPeriods = 0
+
+		for _, occurrence := range occurrences {
+			if occurrence.Before(dtstart) {
+				continue
+			}
+			if rule.Until != nil && occurrence.After(*rule.Until) {
+				return time.Time{}, false, nil
+			}
+			count++
+			if rule.Count > 0 && count > rule.Count {
+				return time.Time{}, false, nil
+			}
+			if occurrence.After(after) {
+				return occurrence, true, nil
+			}
+		}
+	}
+
+	return time.Time{}, false, NoRRuleOccurrenceError
+}
+
+// Index of the period which has the day, 0 for the days before dtstart
+func (rule *RRule) periodOf(dtstart time.Time, day time.Time) int {
+	day = day.In(dtstart.Location())
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, dtstart.Location())
+	if day.Before(dtstart) {
+		return 0
+	}
+
+	units := 0
+	switch rule.Freq {
+	case RRuleFreqDaily:
+		units = daysBetween(dtstart, day)
+	case RRuleFreqWeekly:
+		units = daysBetween(weekStartOf(dtstart), weekStartOf(day)) / 7
+	case RRuleFreqMonthly:
+		units = (day.Year()-dtstart.Year())*12 + int(day.Month()-dtstart.Month())
+	case RRuleFreqYearly:
+		units = day.Year() - dtstart.Year()
+	}
+	return units / rule.Interval
+}
+
+// Number of the occurrences from dtstart in the periods before the period.
+// The periods are looked one by one only when the number depends on the length of the months, until COUNT is reached.
+func (rule *RRule) countBefore(dtstart time.Time, period int) int {
+	if period == 0 {
+		return 0
+	}
+
+	count := 0
+	for _, occurrence := range rule.occurrencesInPeriod(dtstart, 0) {
+		if !occurrence.Before(dtstart) {
+			count++
+		}
+	}
+
+	if n, ok := rule.occurrencesPerPeriod(dtstart); ok {
+		return count + (period-1)*n
+	}
+	for p := 1; p < period && count < rule.Count; p++ {
+		count += len(rule.occurrencesInPeriod(dtstart, p))
+	}
+	return count
+}
+
+// Number of the occurrences in every period, false if it depends on the month
+func (rule *RRule) occurrencesPerPeriod(dtstart time.Time) (int, bool) {
+	switch rule.Freq {
+	case RRuleFreqDaily:
+		return 1, true
+	case RRuleFreqWeekly:
+		if len(rule.ByDay) == 0 {
+			return 1, true
+		}
+		weekdays := map[time.Weekday]bool{}
+		for _, weekday := range rule.ByDay {
+			weekdays[weekday] = true
+		}
+		return len(weekdays), true
+	}
+
+	// Every month has the days from 1 to 28 and from -28 to -1, and they are different days unless both are given
+	monthDays := map[int]bool{}
+	positive, negative := false, false
+	for _, day := range rule.ByMonthDay {
+		monthDays[day] = true
+		positive = positive || day > 0
+		negative = negative || day < 0
+		if day > 28 || day < -28 {
+			return 0, false
+		}
+	}
+	if len(rule.ByMonthDay) == 0 {
+		if dtstart.Day() > 28 {
+			return 0, false
+		}
+		monthDays[dtstart.Day()] = true
+	}
+	if positive && negative {
+		return 0, false
+	}
+
+	months := 1
+	if rule.Freq == RRuleFreqYearly && len(rule.ByMonth) > 0 {
+		uniqueMonths := map[time.Month]bool{}
+		for _, month := range rule.ByMonth {
+			uniqueMonths[month] = true
+		}
+		months = len(uniqueMonths)
+	}
+	return len(monthDays) * months, true
+}
+
+// Weeks start on Monday
+func weekStartOf(day time.Time) time.Time {
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// Days between the dates, which are not always 24 hours with the daylight saving time
+func daysBetween(from time.Time, to time.Time) int {
+	fromDate := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDate.Sub(fromDate).Hours() / 24)
+}
+
+// Occurrences in the period-th DAY, WEEK, MONTH or YEAR of the rule in order
+func (rule *RRule) occurrencesInPeriod(dtstart time.Time, period int) []time.Time {
+	step := period * rule.Interval
+	loc := dtstart.Location()
+	occurrences := []time.Time{}
+
+	switch rule.Freq {
+	case RRuleFreqDaily:
+		occurrences = append(occurrences, dtstart.AddDate(0, 0, step))
+	case RRuleFreqWeekly:
+		weekStart := weekStartOf(dtstart).AddDate(0, 0, 7*step)
+		weekdays := rule.ByDay
+		if len(weekdays) == 0 {
+			weekdays = []time.Weekday{dtstart.Weekday()}
+		}
+		for _, weekday := range weekdays {
+			occurrences = append(occurrences, weekStart.AddDate(0, 0, (int(weekday)+6)%7))
+		}
+	case RRuleFreqMonthly:
+		month := time.Date(dtstart.Year(), dtstart.Month()+time.Month(step), 1, 0, 0, 0, 0, loc)
+		occurrences = append(occurrences, rule.monthDays(month, dtstart)...)
+	case RRuleFreqYearly:
+		months := rule.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{dtstart.Month()}
+		}
+		for _, m := range months {
+			month := time.Date(dtstart.Year()+step, m, 1, 0, 0, 0, 0, loc)
+			occurrences = append(occurrences, rule.monthDays(month, dtstart)...)
+		}
+	}
+
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].Before(occurrences[j]) })
+	unique := occurrences[:0]
+	for idx, occurrence := range occurrences {
+		if idx == 0 || !occurrence.Equal(occurrences[idx-1]) {
+			unique = append(unique, occurrence)
+		}
+	}
+	return unique
+}
+
+func (rule *RRule) monthDays(month time.Time, dtstart time.Time) []time.Time {
+	lastDay := month.AddDate(0, 1, -1).Day()
+	monthDays := rule.ByMonthDay
+	if len(monthDays) == 0 {
+		monthDays = []int{dtstart.Day()}
+	}
+
+	days := []time.Time{}
+	for _, day := range monthDays {
+		if day < 0 {
+			day = lastDay + 1 + day
+		}
+		if day < 1 || day > lastDay {
+			continue
+		}
+		days = append(days, month.AddDate(0, 0, day-1))
+	}
+	return days
+}
@@ -0,0 +1,83 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+// Next of the rule by stepping through every period from dtstart
+func nextByAllPeriods(rule *RRule, dtstart time.Time, after time.Time, periods int) (time.Time, bool) {
+	count := 0
+	for period := 0; period < periods; period++ {
+		for _, occurrence := range rule.occurrencesInPeriod(dtstart, period) {
+			if occurrence.Before(dtstart) {
+				continue
+			}
+			if rule.Until != nil && occurrence.After(*rule.Until) {
+				return time.Time{}, false
+			}
+			count++
+			if rule.Count > 0 && count > rule.Count {
+				return time.Time{}, false
+			}
+			if occurrence.After(after) {
+				return occurrence, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func TestRRuleNext(t *testing.T) {
+	rules := []string{
+		"FREQ=DAILY",
+		"FREQ=DAILY;INTERVAL=3;COUNT=40",
+		"FREQ=WEEKLY;BYDAY=MO,WE,FR",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=SU,TU;COUNT=25",
+		"FREQ=MONTHLY;BYMONTHDAY=27",
+		"FREQ=MONTHLY;BYMONTHDAY=31;COUNT=10",
+		"FREQ=MONTHLY;BYMONTHDAY=-1,15;COUNT=30",
+		"FREQ=MONTHLY;INTERVAL=5;BYMONTHDAY=1,-3;UNTIL=20300101",
+		"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29;COUNT=3",
+		"FREQ=YEARLY;BYMONTH=3,12;BYMONTHDAY=10,20",
+		"FREQ=YEARLY;INTERVAL=2",
+	}
+	dtstarts := []time.Time{
+		time.Date(2024, 1, 31, 9, 0, 0, 0, time.Local),
+		time.Date(2023, 3, 15, 0, 0, 0, 0, time.Local),
+	}
+
+	for _, value := range rules {
+		rule, err := ParseRRule(value)
+		if err != nil {
+			t.Fatal(value, err)
+		}
+		for _, dtstart := range dtstarts {
+			start := time.Date(dtstart.Year(), dtstart.Month(), dtstart.Day(), 0, 0, 0, 0, time.Local)
+			for days := -3; days < 3000; days += 17 {
+				after := dtstart.AddDate(0, 0, days)
+
+				got, gotOk, err := rule.Next(dtstart, after)
+				if err != nil {
+					t.Fatal(value, err)
+				}
+				want, wantOk := nextByAllPeriods(rule, start, after, 5000)
+				if gotOk != wantOk || !got.Equal(want) {
+					t.Errorf("%s from %s after %s: got %s %v, want %s %v", value, dtstart.Format("2006-01-02"), after.Format("2006-01-02"), got, gotOk, want, wantOk)
+				}
+			}
+		}
+	}
+}
+
+func TestRRuleNextNeverOccurs(t *testing.T) {
+	rule, err := ParseRRule("FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dtstart := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	if _, _, err := rule.Next(dtstart, dtstart); err != NoRRuleOccurrenceError {
+		t.Errorf("err = %v, want NoRRuleOccurrenceError", err)
+	}
+}
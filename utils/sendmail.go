@@ -2,27 +2,71 @@ package util
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"net/mail"
 	"net/smtp"
 	"os"
+	"strings"
 
 	"github.com/google/uuid"
 )
 
+var InvalidEmailError = errors.New("Invalid Email")
+
+// Checks that the email is a bare address like user@example.com, without the display name
+func ValidateEmail(email string) error {
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return InvalidEmailError
+	}
+	return nil
+}
+
 func SendRealCreateUserMail(to string, token string) error {
+	apiAddr := os.Getenv("PUBLIC_API_ADDR")
+
+	return sendMail(to, "PLAccounting - メールアドレス確認",
+		"本登録を完了するには、下記URLにてメールアドレス確認を行なってください。\n"+
+			apiAddr+"/createUser?token="+token)
+}
+
+func SendPasswordResetMail(to string, token string) error {
+	frontendAddr := os.Getenv("FRONTEND_ADDR")
+
+	return sendMail(to, "PLAccounting - パスワード再設定",
+		"パスワードを再設定するには、下記URLにて新しいパスワードを設定してください。\n"+
+			"このURLの有効期限は1時間です。心当たりがない場合はこのメールを破棄してください。\n"+
+			frontendAddr+"/resetPassword?token="+token)
+}
+
+func SendEmailChangeMail(to string, token string) error {
+	apiAddr := os.Getenv("PUBLIC_API_ADDR")
+
+	return sendMail(to, "PLAccounting - メールアドレス変更確認",
+		"メールアドレスの変更を完了するには、下記URLにてメールアドレス確認を行なってください。\n"+
+			"このURLの有効期限は1時間です。心当たりがない場合はこのメールを破棄してください。\n"+
+			apiAddr+"/changeEmail?token="+token)
+}
+
+func sendMail(to string, subject string, body string) error {
 	from := os.Getenv("SMTP_USERADDR")
 	user := os.Getenv("SMTP_USER")
 	pass := os.Getenv("SMTP_PASS")
 	host := os.Getenv("SMTP_HOST")
 	port := os.Getenv("SMTP_PORT")
-	apiAddr := os.Getenv("PUBLIC_API_ADDR")
+
+	// CR and LF would start another header
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
+		fmt.Println("Error: ", InvalidEmailError)
+		return InvalidEmailError
+	}
 
 	msg := "From: " + from + "\r\n" +
 		"To: " + to + "\r\n" +
 		"Message-ID: " + "<" + uuid.New().String() + "@" + host + ">\r\n" +
-		"Subject: PLAccounting - メールアドレス確認\r\n\r\n" +
-		"本登録を完了するには、下記URLにてメールアドレス確認を行なってください。\n" +
-		apiAddr + "/createUser?token=" + token
+		"Subject: " + subject + "\r\n\r\n" +
+		body
 
 	fmt.Println("Will send message to ", to)
 
@@ -0,0 +1,360 @@
+package util
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// LastUsedAt is updated at most once in this interval
+const sessionTouchInterval = time.Minute
+
+// A refresh token which was just rotated gets the same new token in this period instead of being treated as reused,
+// so that the requests of the client refreshing at the same time do not revoke the session
+var refreshTokenGracePeriod = 10 * time.Second
+
+// Hours
+const defaultSessionLifeTime = 360
+
+var InvalidSessionError = errors.New("Invalid Session")
+var RefreshTokenReusedError = errors.New("Refresh Token Reused")
+
+// Logged in device of the user, which is stored in Redis until ExpiresAt.
+// Access tokens have the session ID, so deleting the session revokes them.
+// The session is the family of the refresh tokens.
+type Session struct {
+	SessionId        string    `json:"session_id"`
+	UserId           string    `json:"user_id"`
+	Device           string    `json:"device"`
+	IPAddress        string    `json:"ip_address"`
+	UserAgent        string    `json:"user_agent"`
+	CreatedAt        time.Time `json:"created_at"`
+	LastUsedAt       time.Time `json:"last_used_at"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshTokenHash string    `json:"-"`
+}
+
+func sessionKeyOf(sessionId string) string {
+	return "session." + sessionId
+}
+
+// Hash of the latest refresh token of the session.
+// It is not in the session, so that updating LastUsedAt does not overwrite the rotated one.
+func sessionRefreshTokenKeyOf(sessionId string) string {
+	return "session." + sessionId + ".refreshToken"
+}
+
+func userSessionsKeyOf(userId string) string {
+	return userId + ".sessions"
+}
+
+// Key of the refresh token which was rotated, kept until the session expires to detect its reuse
+func usedRefreshTokenKeyOf(refreshTokenHash string) string {
+	return "refreshToken.used." + refreshTokenHash
+}
+
+// Key of the new refresh token which replaced the rotated one, kept for refreshTokenGracePeriod
+func replacingRefreshTokenKeyOf(refreshTokenHash string) string {
+	return "refreshToken.next." + refreshTokenHash
+}
+
+func refreshTokenHashOf(refreshToken string) string {
+	r := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(r[:])
+}
+
+// Lifetime of the session and its refresh tokens in hours by JWT_TOKEN_LIFETIME
+func sessionLifeTime() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("JWT_TOKEN_LIFETIME"))
+	if err != nil || hours <= 0 {
+		hours = defaultSessionLifeTime
+	}
+	return time.Hour * time.Duration(hours)
+}
+
+// Session which is not saved yet, StartSession saves it
+func NewSession(userId string, device string, ipAddress string, userAgent string) Session {
+	now := time.Now()
+	return Session{
+		SessionId:  uuid.New().String(),
+		UserId:     userId,
+		Device:     device,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(sessionLifeTime()),
+	}
+}
+
+// Refresh token is <session ID>.<random>, and only its hash is stored
+func newRefreshToken(session *Session) (string, error) {
+	random, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	refreshToken := session.SessionId + "." + random
+	session.RefreshTokenHash = refreshTokenHashOf(refreshToken)
+	return refreshToken, nil
+}
+
+// Saves the new session and returns its first refresh token
+func StartSession(session *Session) (string, error) {
+	refreshToken, err := newRefreshToken(session)
+	if err != nil {
+		return "", err
+	}
+
+	err = saveSession(session)
+	if err != nil {
+		return "", err
+	}
+
+	return refreshToken, nil
+}
+
+// Replaces the refresh token with a new one and returns the session and the new token.
+// The session expires at the same time, so the user has to log in again after JWT_TOKEN_LIFETIME.
+// A refresh token which was already rotated may be stolen, so its reuse deletes the session with all its tokens.
+// Within refreshTokenGracePeriod after the rotation, the rotated token gets the token which replaced it.
+func RotateRefreshToken(refreshToken string) (Session, string, error) {
+	session, newToken, err := rotateRefreshToken(refreshToken)
+	// Another request rotated the token at the same time, and this one gets its token in the grace period
+	if err == redis.TxFailedErr {
+		session, newToken, err = rotateRefreshToken(refreshToken)
+	}
+	if err == redis.TxFailedErr {
+		return Session{}, "", InvalidSessionError
+	}
+
+	return session, newToken, err
+}
+
+func rotateRefreshToken(refreshToken string) (Session, string, error) {
+	sessionId, _, found := strings.Cut(refreshToken, ".")
+	if !found {
+		return Session{}, "", InvalidSessionError
+	}
+	refreshTokenHash := refreshTokenHashOf(refreshToken)
+	key := sessionRefreshTokenKeyOf(sessionId)
+
+	var session Session
+	var newToken string
+	err := Redis.Watch(Context, func(tx *redis.Tx) error {
+		currentHash, err := tx.Get(Context, key).Result()
+		if err == redis.Nil {
+			return InvalidSessionError
+		}
+		if err != nil {
+			return err
+		}
+		session, err = GetSession(sessionId)
+		if err != nil {
+			return err
+		}
+
+		if subtle.ConstantTimeCompare([]byte(refreshTokenHash), []byte(currentHash)) != 1 {
+			replacingToken, err := tx.Get(Context, replacingRefreshTokenKeyOf(refreshTokenHash)).Result()
+			if err != nil && err != redis.Nil {
+				return err
+			}
+			// The token which replaced it is returned only while it is the latest one
+			if err == nil && subtle.ConstantTimeCompare([]byte(refreshTokenHashOf(replacingToken)), []byte(currentHash)) == 1 {
+				newToken = replacingToken
+				return nil
+			}
+
+			used, err := tx.Exists(Context, usedRefreshTokenKeyOf(refreshTokenHash)).Result()
+			if err != nil {
+				return err
+			}
+			if used > 0 {
+				return RefreshTokenReusedError
+			}
+			return InvalidSessionError
+		}
+
+		newToken, err = newRefreshToken(&session)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(Context, func(pipe redis.Pipeliner) error {
+			pipe.SetArgs(Context, key, session.RefreshTokenHash, redis.SetArgs{Mode: "XX", KeepTTL: true})
+			pipe.Set(Context, usedRefreshTokenKeyOf(refreshTokenHash), sessionId, time.Until(session.ExpiresAt))
+			pipe.Set(Context, replacingRefreshTokenKeyOf(refreshTokenHash), newToken, refreshTokenGracePeriod)
+			return nil
+		})
+		return err
+	}, key)
+
+	if err == RefreshTokenReusedError {
+		fmt.Println("Refresh token was reused, session is revoked: ", sessionId)
+		if err := DeleteSession(session.UserId, sessionId); err != nil && err != InvalidSessionError {
+			return Session{}, "", err
+		}
+		return Session{}, "", RefreshTokenReusedError
+	}
+	if err != nil {
+		if err != InvalidSessionError && err != redis.TxFailedErr {
+			fmt.Println("Error: ", err)
+		}
+		return Session{}, "", err
+	}
+
+	return session, newToken, nil
+}
+
+func saveSession(session *Session) error {
+	ttl := time.Until(session.ExpiresAt)
+	if ttl <= 0 {
+		return InvalidSessionError
+	}
+
+	jsonBytes, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	err = Redis.Set(Context, sessionKeyOf(session.SessionId), string(jsonBytes), ttl).Err()
+	if err != nil {
+		fmt.Println("Error: ", err)
+		return err
+	}
+	err = Redis.Set(Context, sessionRefreshTokenKeyOf(session.SessionId), session.RefreshTokenHash, ttl).Err()
+	if err != nil {
+		fmt.Println("Error: ", err)
+		return err
+	}
+
+	// The set lives as long as the newest session
+	userSessionsKey := userSessionsKeyOf(session.UserId)
+	Redis.SAdd(Context, userSessionsKey, session.SessionId)
+	if currentTTL, err := Redis.TTL(Context, userSessionsKey).Result(); err == nil && currentTTL < ttl {
+		Redis.Expire(Context, userSessionsKey, ttl)
+	}
+
+	return nil
+}
+
+func GetSession(sessionId string) (Session, error) {
+	value, err := Redis.Get(Context, sessionKeyOf(sessionId)).Result()
+	if err == redis.Nil {
+		return Session{}, InvalidSessionError
+	}
+	if err != nil {
+		fmt.Println("Error: ", err)
+		return Session{}, err
+	}
+
+	var session Session
+	err = json.Unmarshal([]byte(value), &session)
+	if err != nil {
+		return Session{}, InvalidSessionError
+	}
+
+	return session, nil
+}
+
+// Updates LastUsedAt, IPAddress and UserAgent of the session in use
+func TouchSession(session *Session, ipAddress string, userAgent string) error {
+	if time.Since(session.LastUsedAt) < sessionTouchInterval && session.IPAddress == ipAddress {
+		return nil
+	}
+
+	session.LastUsedAt = time.Now()
+	session.IPAddress = ipAddress
+	session.UserAgent = userAgent
+
+	jsonBytes, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	// XX not to revive the session deleted meanwhile
+	err = Redis.SetArgs(Context, sessionKeyOf(session.SessionId), string(jsonBytes), redis.SetArgs{Mode: "XX", KeepTTL: true}).Err()
+	if err != nil && err != redis.Nil {
+		fmt.Println("Error: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// Sessions of the user which are not expired
+func GetUserSessions(userId string) ([]Session, error) {
+	sessionIds, err := Redis.SMembers(Context, userSessionsKeyOf(userId)).Result()
+	if err != nil {
+		fmt.Println("Error: ", err)
+		return nil, err
+	}
+
+	sessions := []Session{}
+	for _, sessionId := range sessionIds {
+		session, err := GetSession(sessionId)
+		if err == InvalidSessionError {
+			Redis.SRem(Context, userSessionsKeyOf(userId), sessionId)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// Deletes the session of the user, InvalidSessionError if the user does not have it
+func DeleteSession(userId string, sessionId string) error {
+	session, err := GetSession(sessionId)
+	if err != nil {
+		return err
+	}
+	if session.UserId != userId {
+		return InvalidSessionError
+	}
+
+	err = Redis.Del(Context, sessionKeyOf(sessionId), sessionRefreshTokenKeyOf(sessionId)).Err()
+	if err != nil {
+		fmt.Println("Error: ", err)
+		return err
+	}
+	Redis.SRem(Context, userSessionsKeyOf(userId), sessionId)
+
+	return nil
+}
+
+// Deletes all the sessions of the user except exceptSessionId (empty for all)
+func DeleteUserSessions(userId string, exceptSessionId string) error {
+	sessionIds, err := Redis.SMembers(Context, userSessionsKeyOf(userId)).Result()
+	if err != nil {
+		fmt.Println("Error: ", err)
+		return err
+	}
+
+	for _, sessionId := range sessionIds {
+		if sessionId == exceptSessionId {
+			continue
+		}
+		err = Redis.Del(Context, sessionKeyOf(sessionId), sessionRefreshTokenKeyOf(sessionId)).Err()
+		if err != nil {
+			fmt.Println("Error: ", err)
+			return err
+		}
+		Redis.SRem(Context, userSessionsKeyOf(userId), sessionId)
+	}
+
+	return nil
+}
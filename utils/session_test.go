@@ -0,0 +1,143 @@
+package util
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Connects to the Redis of REDIS_*, and skips the test if it is not set
+func setupTestRedis(t *testing.T) {
+	t.Helper()
+	if os.Getenv("REDIS_HOST") == "" {
+		t.Skip("REDIS_HOST is not set")
+	}
+
+	if Redis == nil {
+		InitRedis()
+	}
+	if Redis == nil {
+		t.Fatal("redis could not connected")
+	}
+	if err := Redis.Ping(Context).Err(); err != nil {
+		t.Fatal("redis could not connected: ", err)
+	}
+}
+
+// Session of a new user, deleted after the test
+func startTestSession(t *testing.T) (Session, string) {
+	t.Helper()
+
+	session := NewSession("test-"+uuid.New().String(), "test", "127.0.0.1", "go test")
+	refreshToken, err := StartSession(&session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		DeleteUserSessions(session.UserId, "")
+	})
+
+	return session, refreshToken
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	setupTestRedis(t)
+	session, refreshToken := startTestSession(t)
+
+	rotated, newToken, err := RotateRefreshToken(refreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.SessionId != session.SessionId || newToken == refreshToken {
+		t.Errorf("session = %s, token changed = %v", rotated.SessionId, newToken != refreshToken)
+	}
+
+	// The new token is rotated as usual
+	_, nextToken, err := RotateRefreshToken(newToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nextToken == newToken {
+		t.Error("token was not rotated")
+	}
+}
+
+// The requests refreshing with the same token at the same time get the same new token
+func TestRotateRefreshTokenConcurrently(t *testing.T) {
+	setupTestRedis(t)
+	session, refreshToken := startTestSession(t)
+
+	const n = 5
+	tokens := make([]string, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for idx := 0; idx < n; idx++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			_, tokens[idx], errs[idx] = RotateRefreshToken(refreshToken)
+		}(idx)
+	}
+	wg.Wait()
+
+	for idx := 0; idx < n; idx++ {
+		if errs[idx] != nil {
+			t.Fatalf("request %d: %v", idx, errs[idx])
+		}
+		if tokens[idx] != tokens[0] {
+			t.Errorf("request %d got another token", idx)
+		}
+	}
+	if _, err := GetSession(session.SessionId); err != nil {
+		t.Errorf("session was revoked: %v", err)
+	}
+	if _, _, err := RotateRefreshToken(tokens[0]); err != nil {
+		t.Errorf("new token: %v", err)
+	}
+}
+
+// A rotated token presented after the grace period is a reuse and revokes the session
+func TestRotateRefreshTokenReused(t *testing.T) {
+	setupTestRedis(t)
+	gracePeriod := refreshTokenGracePeriod
+	refreshTokenGracePeriod = 100 * time.Millisecond
+	t.Cleanup(func() { refreshTokenGracePeriod = gracePeriod })
+
+	session, refreshToken := startTestSession(t)
+	_, newToken, err := RotateRefreshToken(refreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Still in the grace period
+	_, graceToken, err := RotateRefreshToken(refreshToken)
+	if err != nil || graceToken != newToken {
+		t.Fatalf("in the grace period: same token = %v, err = %v", graceToken == newToken, err)
+	}
+
+	time.Sleep(3 * refreshTokenGracePeriod)
+	if _, _, err := RotateRefreshToken(refreshToken); err != RefreshTokenReusedError {
+		t.Fatalf("err = %v, want RefreshTokenReusedError", err)
+	}
+	if _, err := GetSession(session.SessionId); err != InvalidSessionError {
+		t.Errorf("session: err = %v, want InvalidSessionError", err)
+	}
+	if _, _, err := RotateRefreshToken(newToken); err != InvalidSessionError {
+		t.Errorf("new token: err = %v, want InvalidSessionError", err)
+	}
+}
+
+func TestRotateRefreshTokenOfDeletedSession(t *testing.T) {
+	setupTestRedis(t)
+	session, refreshToken := startTestSession(t)
+
+	if err := DeleteSession(session.UserId, session.SessionId); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := RotateRefreshToken(refreshToken); err != InvalidSessionError {
+		t.Errorf("err = %v, want InvalidSessionError", err)
+	}
+}
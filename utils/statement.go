@@ -0,0 +1,151 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+)
+
+const DefaultStatementDateFormat = "2006/1/2"
+
+// Row is 1-based: the row of CSV, the line of QIF or the number of STMTTRN of OFX
+type StatementParseError struct {
+	Row     int
+	Message string
+}
+
+func (e *StatementParseError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+}
+
+// Reads the lines of a statement CSV with the column mapping of the profile.
+// Rows without both withdrawal and deposit, or without the amount of AmountColumn, are skipped.
+func ParseStatementCSV(r io.Reader, profile *model.ImportProfile) ([]model.StatementLine, error) {
+	reader, err := NewCSVReader(r, profile.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	dateFormat := profile.DateFormat
+	if dateFormat == "" {
+		dateFormat = DefaultStatementDateFormat
+	}
+
+	lines := []model.StatementLine{}
+	row := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		row++
+		if err != nil {
+			return nil, &StatementParseError{Row: row, Message: err.Error()}
+		}
+		if row <= profile.SkipRows || isBlankRecord(record) {
+			continue
+		}
+
+		amount, err := statementAmountOf(record, profile)
+		if err != nil {
+			return nil, &StatementParseError{Row: row, Message: err.Error()}
+		}
+		if amount == 0 {
+			continue
+		}
+
+		occurredAt, err := time.ParseInLocation(dateFormat, fieldOf(record, profile.DateColumn), time.Local)
+		if err != nil {
+			return nil, &StatementParseError{Row: row, Message: "date is invalid: " + err.Error()}
+		}
+
+		lines = append(lines, model.StatementLine{
+			Row:         row,
+			OccurredAt:  occurredAt,
+			Description: fieldOf(record, profile.DescriptionColumn),
+			Amount:      amount,
+		})
+	}
+
+	assignStatementExternalIds(lines)
+
+	return lines, nil
+}
+
+// Gives the lines without an ID of the bank the hash of the date, the amount and the description.
+// Identical lines in the statement are told apart by the number of their occurrence.
+func assignStatementExternalIds(lines []model.StatementLine) {
+	occurrences := map[string]int{}
+	for idx := range lines {
+		if lines[idx].ExternalId != "" {
+			continue
+		}
+
+		key := fmt.Sprintf("%s|%d|%s", lines[idx].OccurredAt.Format("2006-01-02"), lines[idx].Amount, lines[idx].Description)
+		occurrences[key]++
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", key, occurrences[key])))
+		lines[idx].ExternalId = "hash:" + hex.EncodeToString(sum[:16])
+	}
+}
+
+// Amount of the row, positive for a deposit and negative for a withdrawal
+func statementAmountOf(record []string, profile *model.ImportProfile) (int64, error) {
+	if profile.AmountColumn >= 0 {
+		amount, err := parseStatementAmount(fieldOf(record, profile.AmountColumn))
+		if err != nil {
+			return 0, fmt.Errorf("amount is invalid: %w", err)
+		}
+		return amount, nil
+	}
+
+	withdrawal, err := parseStatementAmount(fieldOf(record, profile.WithdrawalColumn))
+	if err != nil {
+		return 0, fmt.Errorf("withdrawal is invalid: %w", err)
+	}
+	deposit, err := parseStatementAmount(fieldOf(record, profile.DepositColumn))
+	if err != nil {
+		return 0, fmt.Errorf("deposit is invalid: %w", err)
+	}
+	return deposit - withdrawal, nil
+}
+
+func fieldOf(record []string, column int) string {
+	if column < 0 || column >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[column])
+}
+
+func isBlankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Parses amounts like "1,234", "￥1,234", "1234円" and "1234.00".
+// Negative amounts are like "-1,234" or "▲1,234".
+func parseStatementAmount(field string) (int64, error) {
+	replacer := strings.NewReplacer(",", "", "¥", "", "￥", "", "\\", "", "円", "", " ", "", "　", "", "▲", "-", "△", "-")
+	field = replacer.Replace(field)
+	if field == "" {
+		return 0, nil
+	}
+
+	if integer, fraction, found := strings.Cut(field, "."); found {
+		if strings.Trim(fraction, "0") != "" {
+			return 0, fmt.Errorf("%s has a fraction", field)
+		}
+		field = integer
+	}
+
+	return strconv.ParseInt(field, 10, 64)
+}
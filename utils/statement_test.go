@@ -0,0 +1,93 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	model "github.com/Prokuma/PLAccounting-Backend/models"
+)
+
+func amountsOf(lines []model.StatementLine) []int64 {
+	amounts := make([]int64, 0, len(lines))
+	for _, line := range lines {
+		amounts = append(amounts, line.Amount)
+	}
+	return amounts
+}
+
+func TestParseStatementCSVSignedAmount(t *testing.T) {
+	csv := "日付,摘要,金額\n" +
+		"2024/4/1,給与,\"300,000\"\n" +
+		"2024/4/2,家賃,-80000\n" +
+		"2024/4/3,手数料,▲220\n" +
+		"2024/4/4,残高照会,0\n"
+	profile := model.ImportProfile{
+		Encoding:          CSVEncodingUTF8,
+		SkipRows:          1,
+		DateColumn:        0,
+		DescriptionColumn: 1,
+		WithdrawalColumn:  -1,
+		DepositColumn:     -1,
+		AmountColumn:      2,
+	}
+
+	lines, err := ParseStatementCSV(strings.NewReader(csv), &profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := amountsOf(lines)
+	want := []int64{300000, -80000, -220}
+	if len(got) != len(want) {
+		t.Fatalf("amounts = %v, want %v", got, want)
+	}
+	for idx := range want {
+		if got[idx] != want[idx] {
+			t.Errorf("amounts = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+// The lines in both of the overlapping statements get the same IDs, and identical lines on a day get different ones
+func TestStatementExternalIdsOfOverlappingStatements(t *testing.T) {
+	profile := model.ImportProfile{
+		Encoding:          CSVEncodingUTF8,
+		DateColumn:        0,
+		DescriptionColumn: 1,
+		WithdrawalColumn:  2,
+		DepositColumn:     3,
+		AmountColumn:      -1,
+	}
+	march := "2024/3/30,コンビニ,500,\n" +
+		"2024/3/31,コンビニ,500,\n" +
+		"2024/3/31,コンビニ,500,\n"
+	april := "2024/3/31,コンビニ,500,\n" +
+		"2024/3/31,コンビニ,500,\n" +
+		"2024/4/1,給与,,300000\n"
+
+	marchLines, err := ParseStatementCSV(strings.NewReader(march), &profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aprilLines, err := ParseStatementCSV(strings.NewReader(april), &profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if marchLines[1].ExternalId == marchLines[2].ExternalId {
+		t.Errorf("identical lines got the same ID %s", marchLines[1].ExternalId)
+	}
+	if marchLines[0].ExternalId == marchLines[1].ExternalId {
+		t.Errorf("lines of different days got the same ID %s", marchLines[0].ExternalId)
+	}
+	for idx := 0; idx < 2; idx++ {
+		if marchLines[idx+1].ExternalId != aprilLines[idx].ExternalId {
+			t.Errorf("line %d of 3/31: ID %s in March, %s in April", idx, marchLines[idx+1].ExternalId, aprilLines[idx].ExternalId)
+		}
+	}
+	for _, line := range marchLines {
+		if line.ExternalId == aprilLines[2].ExternalId {
+			t.Errorf("new line of April got the ID %s of March", line.ExternalId)
+		}
+	}
+}
@@ -19,8 +19,9 @@ var Context context.Context
 var Redis *redis.Client
 
 type TokenClaims struct {
-	UserId string `json:"user_id"`
-	Exp    int64  `json:"exp"`
+	UserId    string `json:"user_id"`
+	SessionId string `json:"sid"`
+	Exp       int64  `json:"exp"`
 	jwt.RegisteredClaims
 }
 
@@ -44,7 +45,21 @@ func InitRedis() {
 	})
 }
 
-func GenerateMailConfirmationToken(user *model.User) (string, error) {
+const passwordResetTokenLifeTime = time.Hour
+
+// Minutes
+const defaultAccessTokenLifeTime = 15
+const emailChangeTokenLifeTime = time.Hour
+
+var InvalidPasswordResetTokenError = errors.New("Invalid Password Reset Token")
+var InvalidEmailChangeTokenError = errors.New("Invalid Email Change Token")
+
+type emailChange struct {
+	UserId string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+func generateRandomToken() (string, error) {
 	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
 	b := make([]byte, 32)
@@ -57,6 +72,15 @@ func GenerateMailConfirmationToken(user *model.User) (string, error) {
 		tokenString += string(letters[int(v)%len(letters)])
 	}
 
+	return tokenString, nil
+}
+
+func GenerateMailConfirmationToken(user *model.User) (string, error) {
+	tokenString, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+
 	jsonBytes, err := json.Marshal(user)
 	if err != nil {
 		return "", err
@@ -68,9 +92,19 @@ func GenerateMailConfirmationToken(user *model.User) (string, error) {
 	return tokenString, nil
 }
 
-func GenerateToken(userId string) (string, int, error) {
+// Lifetime of the access token in minutes by JWT_ACCESS_TOKEN_LIFETIME
+func accessTokenLifeTime() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("JWT_ACCESS_TOKEN_LIFETIME"))
+	if err != nil || minutes <= 0 {
+		minutes = defaultAccessTokenLifeTime
+	}
+	return time.Minute * time.Duration(minutes)
+}
+
+// Issues the short-lived access token of the saved session.
+// Returns the token and its lifetime in seconds.
+func GenerateToken(session *Session) (string, int, error) {
 	secretKeyPath := os.Getenv("JWT_PRIVATE_KEY_PATH")
-	tokenLifeTime, err := strconv.Atoi(os.Getenv("JWT_TOKEN_LIFETIME"))
 
 	secretKeyFile, err := os.ReadFile(secretKeyPath)
 	if err != nil {
@@ -84,10 +118,15 @@ func GenerateToken(userId string) (string, int, error) {
 		return "", 0, err
 	}
 
-	exp := time.Now().Add(time.Hour * time.Duration(tokenLifeTime)).Unix()
+	// The token does not outlive the session
+	expiresAt := time.Now().Add(accessTokenLifeTime())
+	if expiresAt.After(session.ExpiresAt) {
+		expiresAt = session.ExpiresAt
+	}
 	claims := TokenClaims{
-		UserId: userId,
-		Exp:    exp,
+		UserId:    session.UserId,
+		SessionId: session.SessionId,
+		Exp:       expiresAt.Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
@@ -97,9 +136,45 @@ func GenerateToken(userId string) (string, int, error) {
 		return "", 0, err
 	}
 
-	Redis.Set(Context, tokenString, strconv.FormatInt(exp, 10), time.Hour*time.Duration(tokenLifeTime))
+	return tokenString, int(time.Until(expiresAt).Seconds()), nil
+}
+
+// Token to reset the password of the user valid for an hour.
+// The previous token of the user is invalidated.
+func GeneratePasswordResetToken(userId string) (string, error) {
+	tokenString, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	prevToken, err := Redis.Get(Context, userId+".passwordReset").Result()
+	if err == nil {
+		Redis.Del(Context, "passwordReset."+prevToken)
+	}
+
+	err = Redis.Set(Context, "passwordReset."+tokenString, userId, passwordResetTokenLifeTime).Err()
+	if err != nil {
+		return "", err
+	}
+	Redis.Set(Context, userId+".passwordReset", tokenString, passwordResetTokenLifeTime)
+
+	return tokenString, nil
+}
+
+// Returns the user ID of the password reset token and deletes the token, so it can be used only once
+func ConsumePasswordResetToken(tokenString string) (string, error) {
+	userId, err := Redis.GetDel(Context, "passwordReset."+tokenString).Result()
+	if err == redis.Nil {
+		return "", InvalidPasswordResetTokenError
+	}
+	if err != nil {
+		fmt.Println("Error: ", err)
+		return "", err
+	}
+
+	Redis.Del(Context, userId+".passwordReset")
 
-	return tokenString, tokenLifeTime, nil
+	return userId, nil
 }
 
 func ParseToken(tokenString string) (*jwt.Token, error) {
@@ -125,3 +200,43 @@ func ParseToken(tokenString string) (*jwt.Token, error) {
 		return nil, errors.New("Invalid Token")
 	}
 }
+
+// Token to confirm the new email of the user valid for an hour
+func GenerateEmailChangeToken(userId string, email string) (string, error) {
+	tokenString, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	jsonBytes, err := json.Marshal(emailChange{UserId: userId, Email: email})
+	if err != nil {
+		return "", err
+	}
+
+	err = Redis.Set(Context, "emailChange."+tokenString, string(jsonBytes), emailChangeTokenLifeTime).Err()
+	if err != nil {
+		return "", err
+	}
+
+	return tokenString, nil
+}
+
+// Returns the user ID and the new email of the token and deletes the token, so it can be used only once
+func ConsumeEmailChangeToken(tokenString string) (string, string, error) {
+	value, err := Redis.GetDel(Context, "emailChange."+tokenString).Result()
+	if err == redis.Nil {
+		return "", "", InvalidEmailChangeTokenError
+	}
+	if err != nil {
+		fmt.Println("Error: ", err)
+		return "", "", err
+	}
+
+	var change emailChange
+	err = json.Unmarshal([]byte(value), &change)
+	if err != nil {
+		return "", "", InvalidEmailChangeTokenError
+	}
+
+	return change.UserId, change.Email, nil
+}